@@ -0,0 +1,155 @@
+package structjson
+
+import "go/types"
+
+// AliasTarget is the definition referred by a true alias (type A = B), following chained aliases.
+type AliasTarget struct {
+	Name    string `json:"name"`    // qualified name (e.g. "models.Person")
+	Package string `json:"package"` // full name of the module defining it
+	Kind    string `json:"kind"`    // struct, interface or alias (a defined type)
+}
+
+// namedRef is a named type with the module defining it.
+type namedRef struct {
+	Module *Module
+	Name   string
+}
+
+// LookupInterface returns the interface definition named name, and the file defining it.
+func (m *Module) LookupInterface(name string) (*Result, *InterfaceDefinition) {
+	for _, r := range m.Files {
+		if def, ok := r.InterfaceMap[name]; ok {
+			return r, def
+		}
+	}
+	return nil, nil
+}
+
+// LookupAlias returns the alias definition named name, and the file defining it.
+func (m *Module) LookupAlias(name string) (*Result, *AliasDefinition) {
+	for _, r := range m.Files {
+		if def, ok := r.AliasMap[name]; ok {
+			return r, def
+		}
+	}
+	return nil, nil
+}
+
+// lookupNamed finds the module defining the named type typ, referenced in r of m. returns nil if typ is not a named type in w.
+func (w *World) lookupNamed(m *Module, r *Result, typ Type) *namedRef {
+	switch t := typ.(type) {
+	case *InstantiationType:
+		return w.lookupNamed(m, r, t.Value)
+	case *PrimitiveType:
+		if t.TypeInfo != nil && t.TypeInfo.Package != "" && t.TypeInfo.Package != m.FullName {
+			m = w.LookupModule(t.TypeInfo.Package)
+		}
+		if m == nil {
+			return nil
+		}
+		return &namedRef{Module: m, Name: t.Value}
+	case *SelectorType:
+		fullname := ""
+		if t.TypeInfo != nil {
+			fullname = t.TypeInfo.Package
+		} else if im, ok := r.ImportsMap[t.Prefix]; ok {
+			fullname = im.FullName
+		}
+		if m := w.LookupModule(fullname); m != nil {
+			return &namedRef{Module: m, Name: t.Value}
+		}
+	}
+	return nil
+}
+
+// followAlias follows the true aliases from ref, and returns the named type finally referred.
+func (w *World) followAlias(ref *namedRef) *namedRef {
+	seen := map[*AliasDefinition]bool{}
+	for ref != nil {
+		r, def := ref.Module.LookupAlias(ref.Name)
+		if def == nil || !def.Alias || seen[def] {
+			return ref
+		}
+		seen[def] = true
+		next := w.lookupNamed(ref.Module, r, def.Original)
+		if next == nil {
+			return ref
+		}
+		ref = next
+	}
+	return nil
+}
+
+// ComputeAliasTargets computes Target of all true aliases in w, whose targets are struct, interface or alias definitions in w.
+func (w *World) ComputeAliasTargets() {
+	for _, m := range w.Modules {
+		for _, r := range m.Files {
+			for _, def := range r.AliasMap {
+				if !def.Alias {
+					continue
+				}
+				def.Target = w.aliasTarget(w.followAlias(w.lookupNamed(m, r, def.Original)))
+			}
+		}
+	}
+}
+
+func (w *World) aliasTarget(ref *namedRef) *AliasTarget {
+	if ref == nil {
+		return nil
+	}
+	target := &AliasTarget{Name: ref.Module.Name + "." + ref.Name, Package: ref.Module.FullName}
+	if _, def := ref.Module.LookupStruct(ref.Name); def != nil {
+		target.Kind = "struct"
+	} else if _, def := ref.Module.LookupInterface(ref.Name); def != nil {
+		target.Kind = "interface"
+	} else if _, def := ref.Module.LookupAlias(ref.Name); def != nil {
+		target.Kind = "alias"
+	} else {
+		return nil
+	}
+	return target
+}
+
+// ComputeAliasKinds computes Kind of all alias definitions in w, not computed by type-checking.
+// a definition referring to a named type outside of w is left as "".
+func (w *World) ComputeAliasKinds() {
+	for _, m := range w.Modules {
+		for _, r := range m.Files {
+			for _, def := range r.AliasMap {
+				def.Kind = w.aliasKind(m, r, def, map[*AliasDefinition]bool{})
+			}
+		}
+	}
+}
+
+func (w *World) aliasKind(m *Module, r *Result, def *AliasDefinition, seen map[*AliasDefinition]bool) string {
+	if def.Kind != "" || seen[def] {
+		return def.Kind
+	}
+	seen[def] = true
+	switch t := def.Original.(type) {
+	case *PrimitiveType:
+		if obj, ok := types.Universe.Lookup(t.Value).(*types.TypeName); ok {
+			return underlyingKind(obj.Type()) // e.g. int, error
+		}
+	case *SelectorType, *InstantiationType:
+	default:
+		return def.Original.Kind()
+	}
+
+	ref := w.lookupNamed(m, r, def.Original)
+	if ref == nil {
+		return ""
+	}
+	if _, def := ref.Module.LookupStruct(ref.Name); def != nil {
+		return "struct"
+	}
+	if _, def := ref.Module.LookupInterface(ref.Name); def != nil {
+		return "interface"
+	}
+	if r, def := ref.Module.LookupAlias(ref.Name); def != nil {
+		return w.aliasKind(ref.Module, r, def, seen)
+	}
+	return ""
+}
@@ -1,13 +1,11 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
-	"go/ast"
 	"os"
-	"path"
-	"path/filepath"
 
 	"github.com/podhmo/go-structjson"
 )
@@ -15,9 +13,11 @@ import (
 var target = flag.String("target", "", "target")
 
 type FuncDefinition struct {
-	Name    string  `json:"name"`
-	Params  []Value `json:"params"`
-	Returns []Value `json:"returns"`
+	Name     string  `json:"name"`
+	Receiver string  `json:"receiver,omitempty"` // name of the receiver type, if method
+	Pointer  bool    `json:"pointer,omitempty"`  // pointer receiver
+	Params   []Value `json:"params"`
+	Returns  []Value `json:"returns"`
 }
 type Value struct {
 	Name string          `json:"name"`
@@ -35,6 +35,7 @@ type Module struct {
 type File struct {
 	Name       string                                  `json:"name"`
 	FuncMap    map[string]*FuncDefinition              `json:"function"`
+	MethodMap  map[string]*FuncDefinition              `json:"method,omitempty"` // keyed by "<receiver>.<name>"
 	ImportsMap map[string]*structjson.ImportDefinition `json:"import,omitempty"`
 }
 
@@ -50,6 +51,7 @@ func NewFile(name string) *File {
 	return &File{
 		Name:       name,
 		FuncMap:    make(map[string]*FuncDefinition),
+		MethodMap:  make(map[string]*FuncDefinition),
 		ImportsMap: make(map[string]*structjson.ImportDefinition),
 	}
 }
@@ -61,62 +63,45 @@ func NewModule(name string) *Module {
 	return &Module{Name: name, Files: make(map[string]*File)}
 }
 
-func parse(world *World, fpath string, used map[string]struct{}) error {
-	_, exists := used[fpath]
-	if exists {
-		return nil
+func values(params []*structjson.Param) []Value {
+	vs := make([]Value, 0, len(params))
+	for _, p := range params {
+		vs = append(vs, Value{Name: p.Name, Type: p.Type})
 	}
-	used[fpath] = struct{}{}
+	return vs
+}
 
-	pkgs, err := structjson.CollectPackageMap(fpath)
+func parse(ctx context.Context, world *World, patterns []string) error {
+	src, err := structjson.Load(ctx, structjson.Config{
+		Patterns:       patterns,
+		Main:           true,
+		Funcs:          true,
+		TypeVisibility: structjson.VisibilityAll,
+	})
 	if err != nil {
 		return err
 	}
-	gosrc := path.Join(os.Getenv("GOPATH"), "src")
-	r := structjson.NewResult("")
-	for _, pkg := range pkgs {
-		if pkg == nil {
-			continue
-		}
-		module := NewModule(pkg.Name)
-		world.Modules[pkg.Name] = module
-		for fname, f := range pkg.Files {
-			if f == nil {
-				continue
-			}
-			if module.FullName == "" {
-				if stat, err := os.Stat(fname); err == nil {
-					if stat.IsDir() {
-						module.FullName = fname[len(gosrc)+1:]
-					} else {
-						module.FullName = filepath.Dir(fname)[len(gosrc)+1:]
-					}
-				}
-			}
+	for name, m := range src.Modules {
+		module := NewModule(m.Name)
+		module.FullName = m.FullName
+		world.Modules[name] = module
+		for fname, r := range m.Files {
 			file := NewFile(fname)
 			module.Files[file.Name] = file
-			file.ImportsMap = structjson.CollectImports(f.Imports)
-			for _, node := range f.Decls {
-				switch node := node.(type) {
-				case *ast.FuncDecl:
-					fdef := NewFuncDefinition(node.Name.Name)
-					if node.Type.Results != nil {
-						for _, p := range node.Type.Params.List {
-							fdef.Params = append(fdef.Params, Value{Name: p.Names[0].Name, Type: structjson.FindType(r, p.Type)})
-						}
-					}
-					if node.Type.Results != nil {
-						for _, p := range node.Type.Results.List {
-							if p.Names != nil {
-								fdef.Returns = append(fdef.Returns, Value{Name: p.Names[0].Name, Type: structjson.FindType(r, p.Type)})
-							} else {
-								fdef.Returns = append(fdef.Returns, Value{Type: structjson.FindType(r, p.Type)})
-							}
-						}
-					}
-					file.FuncMap[fdef.Name] = fdef
-					// spew.Dump(node)
-				}
+			file.ImportsMap = r.ImportsMap
+			for _, f := range r.FuncMap {
+				fdef := NewFuncDefinition(f.Name)
+				fdef.Params = values(f.Params)
+				fdef.Returns = values(f.Returns)
+				file.FuncMap[fdef.Name] = fdef
+			}
+			for _, m := range r.Methods {
+				fdef := NewFuncDefinition(m.Name)
+				fdef.Receiver = m.Receiver
+				fdef.Pointer = m.Pointer
+				fdef.Params = values(m.Params)
+				fdef.Returns = values(m.Results)
+				file.MethodMap[m.Receiver+"."+fdef.Name] = fdef
 			}
 		}
 	}
@@ -125,24 +110,23 @@ func parse(world *World, fpath string, used map[string]struct{}) error {
 
 func main() {
 	flag.Parse()
-	args := flag.Args()
-	_ = args
-	if *target == "" {
-		fmt.Fprintf(os.Stderr, "go-structjson --target [target]\n")
+	patterns := flag.Args()
+	if *target != "" {
+		patterns = append([]string{*target}, patterns...)
+	}
+	if len(patterns) == 0 {
+		fmt.Fprintf(os.Stderr, "go-funcjson [--target target] [pattern ...]\n")
 		os.Exit(1)
 	}
 	world := NewWorld()
-	used := map[string]struct{}{} // fpath
-	fpath, err := filepath.Abs(*target)
-	if err != nil {
-		panic(err)
-	}
-	if err := parse(world, fpath, used); err != nil {
-		panic(err)
+	if err := parse(context.Background(), world, patterns); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 	encoder := json.NewEncoder(os.Stdout)
 	encoder.SetIndent("", "  ")
 	if err := encoder.Encode(world); err != nil {
-		panic(err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 }
@@ -1,171 +1,229 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
-	"path"
-	"path/filepath"
-	"runtime"
-	"sort"
 	"strings"
 
 	structjson "github.com/podhmo/go-structjson"
+	"github.com/podhmo/go-structjson/emitter"
+	"github.com/podhmo/go-structjson/emitter/graphql"
+	"github.com/podhmo/go-structjson/emitter/jsonschema"
+	"github.com/podhmo/go-structjson/emitter/openapi"
+	"github.com/podhmo/go-structjson/emitter/protobuf"
+	"github.com/podhmo/go-structjson/emitter/typescript"
 )
 
-// TODO: support iota
-// TODO: comment extraction
-
 var target = flag.String("target", "", "target")
 var verbose = flag.Bool("verbose", false, "verbose")
-var exclude = flag.String("exclude", "fmt,log,reflect,go/ast,unsafe,html/template,text/template,encoding/xml,syscall,windows,encoding/binary,sync,os,flag,net/http,go/format,encoding/json,sys,bufio,bytes/buffer,unicode,sync/atomic", "")
-
-type App struct {
-	gopath     string
-	goroot     string
-	verbose    bool
-	excludeMap map[string]struct{}
-	used       map[string]struct{}
+var typed = flag.Bool("typed", false, "resolve types with go/types")
+var relative = flag.Bool("relative", false, "filenames of positions are relative to the module root")
+var diagnostics = flag.String("diagnostics", "text", "format of diagnostics written to stderr (text, json)")
+var strict = flag.Bool("strict", false, "exit with non-zero status, if warnings are found")
+var exclude = flag.String("exclude", strings.Join(structjson.DefaultExclude, ","), "comma separated package paths not to be collected (\"path/...\" matches sub packages)")
+var include = flag.String("include", "", "comma separated package paths to be collected (default: all)")
+var depth = flag.Int("depth", -1, "depth of recursion into imported packages (negative is unlimited)")
+var tests = flag.Bool("tests", false, "also collect _test.go files")
+var typeVisibility = flag.String("types", "exported", "visibility of types (exported, all)")
+var fieldVisibility = flag.String("fields", "all", "visibility of struct fields (exported, all)")
+var includeMain = flag.Bool("main", false, "also collect main packages")
+var format = flag.String("format", "json", "output format (json, jsonschema, openapi, typescript, protobuf, graphql)")
+var wellknown = flag.String("wellknown", "", "additional well-known types for jsonschema and openapi (e.g. \"time.Time=string:date-time,example.com/x.ID=integer\")")
+var output = flag.String("output", "", "output directory of the formats emitting files (typescript, protobuf, graphql), written to stdout if empty")
+var constEnum = flag.Bool("const-enum", false, "emit enums as const enum (typescript)")
+var input = flag.Bool("input", false, "also emit input types (graphql)")
+
+func splitList(s string) []string {
+	var list []string
+	for _, x := range strings.Split(s, ",") {
+		if x = strings.TrimSpace(x); x != "" {
+			list = append(list, x)
+		}
+	}
+	return list
 }
 
-func (app *App) parse(world *structjson.World, fpath string, pkgName string, depth int) error {
-	_, exists := app.used[fpath]
-	if exists {
-		return nil
-	}
-	app.used[fpath] = struct{}{}
+// formats are the output formats, also usable as subcommands (e.g. go-structjson openapi ./...).
+var formats = []string{"json", "jsonschema", "openapi", "typescript", "protobuf", "graphql"}
 
-	if _, exists := app.excludeMap[pkgName]; exists {
-		if app.verbose {
-			fmt.Fprintf(os.Stderr, "%sparse: skip %q\n", strings.Repeat(" ", depth), pkgName)
+func main() {
+	var subcommand string
+	if len(os.Args) > 1 {
+		for _, name := range formats {
+			if os.Args[1] == name {
+				subcommand = name
+				os.Args = append(os.Args[:1], os.Args[2:]...)
+				break
+			}
 		}
-		return nil
 	}
-	if app.verbose {
-		fmt.Fprintf(os.Stderr, "%sparse: %q\n", strings.Repeat(" ", depth), fpath)
+	flag.Parse()
+	if subcommand != "" {
+		*format = subcommand
+	}
+	patterns := flag.Args()
+	if *target != "" {
+		patterns = append([]string{*target}, patterns...)
+	}
+	if len(patterns) == 0 {
+		fmt.Fprintf(os.Stderr, "go-structjson [%s] [--target target] [pattern ...]\n", strings.Join(formats, "|"))
+		os.Exit(1)
 	}
 
-	pkgs, err := structjson.CollectPackageMap(fpath)
+	tv, err := structjson.ParseVisibility(*typeVisibility)
 	if err != nil {
-		return err
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
-
-	pkgNameList := make([]string, len(pkgs))
-	i := 0
-	for _, pkg := range pkgs {
-		pkgNameList[i] = pkg.Name
-		i++
+	fv, err := structjson.ParseVisibility(*fieldVisibility)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
-	sort.Sort(sort.StringSlice(pkgNameList))
-
-	gosrc := path.Join(app.gopath, "src")
-	for _, pkgName := range pkgNameList {
-		pkg := pkgs[pkgName]
-
-		// skip main
-		if pkg.Name == "main" {
-			continue
+	config := structjson.Config{
+		Patterns:        patterns,
+		Include:         splitList(*include),
+		Exclude:         splitList(*exclude),
+		Depth:           *depth,
+		Tests:           *tests,
+		Main:            *includeMain,
+		Typed:           *typed,
+		RelativePath:    *relative,
+		TypeVisibility:  tv,
+		FieldVisibility: fv,
+	}
+	if *verbose {
+		config.Logf = func(format string, args ...interface{}) {
+			fmt.Fprintf(os.Stderr, format+"\n", args...)
 		}
+	}
+	world, err := structjson.Load(context.Background(), config)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := emit(world, *format); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 
-		module := structjson.NewModule(pkg.Name)
-		world.Modules[pkg.Name] = module
+	if err := writeDiagnostics(world.Diagnostics, *diagnostics, *verbose); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if world.Diagnostics.Count(structjson.SeverityError) > 0 || (*strict && world.Diagnostics.Count(structjson.SeverityWarning) > 0) {
+		os.Exit(1)
+	}
+}
 
-		fileNameList := make([]string, len(pkg.Files))
-		i := 0
-		for fname := range pkg.Files {
-			fileNameList[i] = fname
-			i++
+func emit(world *structjson.World, format string) error {
+	var v interface{}
+	switch format {
+	case "json":
+		v = world
+	case "jsonschema":
+		config, err := schemaConfig()
+		if err != nil {
+			return err
 		}
-		sort.Sort(sort.StringSlice(fileNameList))
-
-		for _, fname := range fileNameList {
-			f := pkg.Files[fname]
-			if module.FullName == "" {
-				if strings.HasPrefix(fname, app.goroot) {
-					module.FullName = module.Name
-				} else {
-					if stat, err := os.Stat(fname); err == nil {
-						if stat.IsDir() {
-							module.FullName = fname[len(gosrc)+1:]
-						} else {
-							module.FullName = filepath.Dir(fname)[len(gosrc)+1:]
-						}
-					}
-				}
-			}
-			// skip test code
-			if strings.HasSuffix(fname, "_test.go") {
-				continue
-			}
-
-			result, err := structjson.CollectResult(fname, f.Scope, f.Imports)
-			if err != nil {
-				return err
-			}
-			// skip no contents
-			if len(result.AliasMap) == 0 && len(result.StructMap) == 0 && len(result.InterfaceMap) == 0 {
-				continue
-			}
-			module.Files[fname] = result
-			for _, im := range result.ImportsMap {
-				if im.NeedParse && strings.Contains(im.FullName, "/") {
-					// pseudo imports
-					{
-						dpath := path.Join(gosrc, im.FullName)
-						if _, err := os.Stat(dpath); err == nil {
-							if err := app.parse(world, dpath, im.FullName, depth+1); err != nil {
-								return err
-							}
-						}
-					}
-				} else {
-					{
-						dpath := path.Join(app.goroot, "src", im.FullName)
-						if _, err := os.Stat(dpath); err == nil {
-							if err := app.parse(world, dpath, im.FullName, depth+1); err != nil {
-								return err
-							}
-						}
-					}
-				}
-			}
+		schema, err := jsonschema.Emit(world, config)
+		if err != nil {
+			return err
+		}
+		v = schema
+	case "openapi":
+		sconfig, err := schemaConfig()
+		if err != nil {
+			return err
+		}
+		config := openapi.NewConfig()
+		config.JSONSchema = sconfig
+		doc, err := openapi.Emit(world, config)
+		if err != nil {
+			return err
+		}
+		v = doc
+	case "typescript":
+		config := typescript.NewConfig()
+		config.ConstEnum = *constEnum
+		files, err := typescript.Emit(world, config)
+		if err != nil {
+			return err
 		}
+		return writeFiles(files)
+	case "protobuf":
+		files, err := protobuf.Emit(world, protobuf.NewConfig())
+		if err != nil {
+			return err
+		}
+		return writeFiles(files)
+	case "graphql":
+		config := graphql.NewConfig()
+		config.Input = *input
+		schema, err := graphql.Emit(world, config)
+		if err != nil {
+			return err
+		}
+		return writeFiles([]*emitter.File{{Path: "schema.graphql", Content: schema}})
+	default:
+		return fmt.Errorf("unknown format %q", format)
 	}
-	return nil
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
 }
 
-func main() {
-	flag.Parse()
-	args := flag.Args()
-	_ = args
-	if *target == "" {
-		fmt.Fprintf(os.Stderr, "go-structjson --target [target]\n")
-		os.Exit(1)
+func writeFiles(files []*emitter.File) error {
+	if *output != "" {
+		return emitter.WriteFiles(*output, files)
 	}
-
-	world := structjson.NewWorld()
-	fpath, err := filepath.Abs(*target)
-	if err != nil {
-		panic(err)
+	if len(files) == 1 {
+		_, err := os.Stdout.Write(files[0].Content)
+		return err
 	}
-	excludeMap := map[string]struct{}{}
-	for _, name := range strings.Split(*exclude, ",") {
-		excludeMap[strings.TrimSpace(name)] = struct{}{}
+	for i, f := range files {
+		if i > 0 {
+			fmt.Fprintln(os.Stdout)
+		}
+		fmt.Fprintf(os.Stdout, "// file: %s\n", f.Path)
+		if _, err := os.Stdout.Write(f.Content); err != nil {
+			return err
+		}
 	}
-	app := App{
-		gopath:     os.Getenv("GOPATH"),
-		goroot:     runtime.GOROOT(),
-		verbose:    *verbose,
-		used:       map[string]struct{}{},
-		excludeMap: excludeMap,
+	return nil
+}
+
+func schemaConfig() (*jsonschema.Config, error) {
+	config := jsonschema.NewConfig()
+	mappings, err := jsonschema.ParseWellKnown(*wellknown)
+	if err != nil {
+		return nil, err
 	}
-	if err := app.parse(world, fpath, "", 0); err != nil {
-		panic(err)
+	for name, s := range mappings {
+		config.WellKnown[name] = s
 	}
-	encoder := json.NewEncoder(os.Stdout)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(world); err != nil {
-		panic(err)
+	return config, nil
+}
+
+func writeDiagnostics(ds structjson.Diagnostics, format string, verbose bool) error {
+	switch format {
+	case "json":
+		return ds.WriteJSON(os.Stderr)
+	case "text":
+		if !verbose {
+			var filtered structjson.Diagnostics
+			for _, d := range ds {
+				if d.Severity != structjson.SeverityInfo {
+					filtered = append(filtered, d)
+				}
+			}
+			ds = filtered
+		}
+		return ds.WriteText(os.Stderr)
+	default:
+		return fmt.Errorf("unknown diagnostics format %q", format)
 	}
 }
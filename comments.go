@@ -0,0 +1,39 @@
+package structjson
+
+import (
+	"go/ast"
+	"strings"
+)
+
+// commentText returns the text of cg, without comment markers. if cg is nil, returns "".
+func commentText(cg *ast.CommentGroup) string {
+	if cg == nil {
+		return ""
+	}
+	return strings.TrimSpace(cg.Text())
+}
+
+// collectGenDecls returns the parent GenDecl of each spec in decls.
+func collectGenDecls(decls []ast.Decl) map[ast.Spec]*ast.GenDecl {
+	m := map[ast.Spec]*ast.GenDecl{}
+	for _, decl := range decls {
+		if decl, ok := decl.(*ast.GenDecl); ok {
+			for _, spec := range decl.Specs {
+				m[spec] = decl
+			}
+		}
+	}
+	return m
+}
+
+// findDoc returns the doc comment of spec. for the spec of not grouped declaration (e.g. type X struct{}),
+// the comment is attached to GenDecl, so it is used.
+func (r *Result) findDoc(spec ast.Spec, doc *ast.CommentGroup) string {
+	if doc != nil {
+		return commentText(doc)
+	}
+	if decl, ok := r.genDecls[spec]; ok && !decl.Lparen.IsValid() {
+		return commentText(decl.Doc)
+	}
+	return ""
+}
@@ -0,0 +1,326 @@
+package structjson
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+)
+
+// constSpec is a declared constant, with implicit repetition in const block resolved.
+type constSpec struct {
+	Name  *ast.Ident
+	Type  ast.Expr // nil if untyped
+	Value ast.Expr // nil if not found
+	Iota  int
+}
+
+type constResult struct {
+	Value    constant.Value
+	TypeName string // "" if untyped
+}
+
+// constEvaluator evaluates constant expressions (iota, arithmetic, string concatenation, references to other consts).
+type constEvaluator struct {
+	specs      map[string]*constSpec
+	types      map[string]ast.Expr // declared types, to find the precision of unsigned types
+	results    map[string]*constResult
+	evaluating map[string]bool
+}
+
+func newConstEvaluator(decls []ast.Decl) *constEvaluator {
+	e := &constEvaluator{
+		specs:      map[string]*constSpec{},
+		types:      map[string]ast.Expr{},
+		results:    map[string]*constResult{},
+		evaluating: map[string]bool{},
+	}
+	for _, decl := range decls {
+		decl, ok := decl.(*ast.GenDecl)
+		if ok && decl.Tok == token.TYPE {
+			for _, spec := range decl.Specs {
+				spec := spec.(*ast.TypeSpec)
+				e.types[spec.Name.Name] = spec.Type
+			}
+			continue
+		}
+		if !ok || decl.Tok != token.CONST {
+			continue
+		}
+		var typ ast.Expr
+		var values []ast.Expr
+		for i, spec := range decl.Specs {
+			spec := spec.(*ast.ValueSpec)
+			if spec.Type != nil || len(spec.Values) > 0 {
+				typ = spec.Type
+				values = spec.Values
+			}
+			for j, name := range spec.Names {
+				cs := &constSpec{Name: name, Type: typ, Iota: i}
+				if j < len(values) {
+					cs.Value = values[j]
+				}
+				e.specs[name.Name] = cs
+			}
+		}
+	}
+	return e
+}
+
+// specOf returns the spec of the const declared as ob.
+// if ob is not found in declarations (e.g. collecting from only scope), implicit repetition is not supported.
+func (e *constEvaluator) specOf(ob *ast.Object) *constSpec {
+	if cs, ok := e.specs[ob.Name]; ok {
+		return cs
+	}
+	spec, ok := ob.Decl.(*ast.ValueSpec)
+	if !ok {
+		return nil
+	}
+	iota, _ := ob.Data.(int)
+	for j, name := range spec.Names {
+		if name.Name != ob.Name {
+			continue
+		}
+		cs := &constSpec{Name: name, Type: spec.Type, Iota: iota}
+		if j < len(spec.Values) {
+			cs.Value = spec.Values[j]
+		}
+		e.specs[ob.Name] = cs
+		return cs
+	}
+	return nil
+}
+
+func (e *constEvaluator) Eval(ob *ast.Object) (*constResult, error) {
+	if result, ok := e.results[ob.Name]; ok {
+		return result, nil
+	}
+	if e.evaluating[ob.Name] {
+		return nil, fmt.Errorf("const %s is defined recursively", ob.Name)
+	}
+	cs := e.specOf(ob)
+	if cs == nil || cs.Value == nil {
+		return nil, fmt.Errorf("const %s is not found", ob.Name)
+	}
+	e.evaluating[ob.Name] = true
+	defer delete(e.evaluating, ob.Name)
+
+	result, err := e.eval(cs.Value, cs.Iota)
+	if err != nil {
+		return nil, err
+	}
+	if cs.Type != nil {
+		ident, ok := cs.Type.(*ast.Ident)
+		if !ok {
+			return nil, fmt.Errorf("const %s has unsupported type %T", ob.Name, cs.Type)
+		}
+		result = &constResult{Value: result.Value, TypeName: ident.Name}
+	}
+	e.results[ob.Name] = result
+	return result, nil
+}
+
+func (e *constEvaluator) eval(expr ast.Expr, iota int) (*constResult, error) {
+	switch expr := expr.(type) {
+	case *ast.BasicLit:
+		v := constant.MakeFromLiteral(expr.Value, expr.Kind, 0)
+		if v.Kind() == constant.Unknown {
+			return nil, fmt.Errorf("invalid literal %s", expr.Value)
+		}
+		return &constResult{Value: v}, nil
+	case *ast.Ident:
+		switch expr.Name {
+		case "iota":
+			return &constResult{Value: constant.MakeInt64(int64(iota))}, nil
+		case "true", "false":
+			return &constResult{Value: constant.MakeBool(expr.Name == "true")}, nil
+		}
+		ob := expr.Obj
+		if ob == nil {
+			ob = &ast.Object{Kind: ast.Con, Name: expr.Name}
+		}
+		if ob.Kind != ast.Con {
+			return nil, fmt.Errorf("%s is not const", expr.Name)
+		}
+		return e.Eval(ob)
+	case *ast.ParenExpr:
+		return e.eval(expr.X, iota)
+	case *ast.UnaryExpr:
+		x, err := e.eval(expr.X, iota)
+		if err != nil {
+			return nil, err
+		}
+		return &constResult{Value: constant.UnaryOp(expr.Op, x.Value, e.prec(x.TypeName)), TypeName: x.TypeName}, nil
+	case *ast.BinaryExpr:
+		x, err := e.eval(expr.X, iota)
+		if err != nil {
+			return nil, err
+		}
+		y, err := e.eval(expr.Y, iota)
+		if err != nil {
+			return nil, err
+		}
+		switch expr.Op {
+		case token.SHL, token.SHR:
+			s, ok := constant.Uint64Val(y.Value)
+			if !ok {
+				return nil, fmt.Errorf("invalid shift count %s", y.Value)
+			}
+			return &constResult{Value: constant.Shift(x.Value, expr.Op, uint(s)), TypeName: x.TypeName}, nil
+		case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+			return &constResult{Value: constant.MakeBool(constant.Compare(x.Value, expr.Op, y.Value))}, nil
+		}
+		op := expr.Op
+		if op == token.QUO && x.Value.Kind() == constant.Int && y.Value.Kind() == constant.Int {
+			op = token.QUO_ASSIGN // integer division
+		}
+		v := constant.BinaryOp(x.Value, op, y.Value)
+		if v.Kind() == constant.Unknown {
+			return nil, fmt.Errorf("invalid operation %s", expr.Op)
+		}
+		typeName := x.TypeName
+		if typeName == "" {
+			typeName = y.TypeName
+		}
+		return &constResult{Value: v, TypeName: typeName}, nil
+	case *ast.CallExpr:
+		fun, ok := expr.Fun.(*ast.Ident)
+		if !ok || len(expr.Args) != 1 {
+			return nil, fmt.Errorf("unsupported call")
+		}
+		x, err := e.eval(expr.Args[0], iota)
+		if err != nil {
+			return nil, err
+		}
+		if fun.Name == "len" && x.Value.Kind() == constant.String {
+			return &constResult{Value: constant.MakeInt64(int64(len(constant.StringVal(x.Value))))}, nil
+		}
+		// conversion (e.g. PersonGender("female"))
+		return &constResult{Value: x.Value, TypeName: fun.Name}, nil
+	default:
+		return nil, fmt.Errorf("unsupported expression %T", expr)
+	}
+}
+
+// prec returns the precision of the type named name for ^x, the size in bits if it is an unsigned integer type, otherwise 0.
+// the types declared in the files not given to newConstEvaluator are not found.
+func (e *constEvaluator) prec(name string) uint {
+	seen := map[string]bool{}
+	for !seen[name] {
+		seen[name] = true
+		switch name {
+		case "uint8", "byte":
+			return 8
+		case "uint16":
+			return 16
+		case "uint32":
+			return 32
+		case "uint", "uint64", "uintptr":
+			return 64
+		}
+		t, ok := e.types[name].(*ast.Ident)
+		if !ok {
+			return 0
+		}
+		name = t.Name
+	}
+	return 0
+}
+
+// evalConst evaluates the const declared as ob. if the result is type-checked, go/types's value is used.
+func (r *Result) evalConst(ob *ast.Object) (*constResult, error) {
+	if r.info != nil {
+		if spec, ok := ob.Decl.(*ast.ValueSpec); ok {
+			for _, name := range spec.Names {
+				if name.Name != ob.Name {
+					continue
+				}
+				c, ok := r.info.Defs[name].(*types.Const)
+				if !ok {
+					break
+				}
+				result := &constResult{Value: c.Val()}
+				if named, ok := types.Unalias(c.Type()).(*types.Named); ok && named.Obj().Pkg() == c.Pkg() {
+					result.TypeName = named.Obj().Name()
+				}
+				return result, nil
+			}
+		}
+	}
+	return r.consts.Eval(ob)
+}
+
+// arrayLen evaluates the length of the array type node. returns -1 if not evaluated.
+// if the result is not type-checked, the constants are evaluated with the declarations of all files in the package (when collected by Load or CollectFileResult).
+func (r *Result) arrayLen(node *ast.ArrayType) int64 {
+	if r.info != nil {
+		if t, ok := r.info.TypeOf(node).(*types.Array); ok {
+			return t.Len()
+		}
+	}
+	var err error
+	if _, ok := node.Len.(*ast.Ellipsis); ok {
+		err = fmt.Errorf("[...] is not supported")
+	} else {
+		var result *constResult
+		if result, err = r.consts.eval(node.Len, 0); err == nil {
+			if n, ok := constant.Int64Val(constant.ToInt(result.Value)); ok && n >= 0 {
+				return n
+			}
+			err = fmt.Errorf("invalid length %s", result.Value)
+		}
+	}
+	r.addDiagnostic(SeverityWarning, node, fmt.Sprintf("array length %s is not evaluated (%s)", types.ExprString(node.Len), err))
+	return -1
+}
+
+// constKind returns the kind name of v (e.g. "string", "int").
+func constKind(v constant.Value) string {
+	switch v.Kind() {
+	case constant.Bool:
+		return "bool"
+	case constant.String:
+		return "string"
+	case constant.Int:
+		return "int"
+	case constant.Float:
+		return "float"
+	case constant.Complex:
+		return "complex"
+	default:
+		return "unknown"
+	}
+}
+
+// constString returns the representation of v as in source (e.g. "\"female\"", "1", "0.5").
+func constString(v constant.Value) string {
+	if v.Kind() == constant.Float {
+		return v.String()
+	}
+	return v.ExactString()
+}
+
+// constValue returns v as go's value for JSON (an integer not fitting in 64bit is a string).
+func constValue(v constant.Value) interface{} {
+	switch v.Kind() {
+	case constant.Bool:
+		return constant.BoolVal(v)
+	case constant.String:
+		return constant.StringVal(v)
+	case constant.Int:
+		if i, ok := constant.Int64Val(v); ok {
+			return i
+		}
+		if u, ok := constant.Uint64Val(v); ok {
+			return u
+		}
+		return v.ExactString()
+	case constant.Float:
+		f, _ := constant.Float64Val(v)
+		return f
+	default:
+		return v.String()
+	}
+}
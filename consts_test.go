@@ -0,0 +1,107 @@
+package structjson
+
+import (
+	"fmt"
+	"go/ast"
+	"reflect"
+	"testing"
+)
+
+func TestConstEvaluator(t *testing.T) {
+	_, m := loadTestdata(t, "consts")
+	r, _ := m.LookupAlias("Flags")
+	cases := []struct {
+		name     string
+		want     interface{}
+		typeName string
+	}{
+		{name: "FlagA", want: int64(1), typeName: "Flags"},
+		{name: "FlagC", want: int64(4), typeName: "Flags"},
+		{name: "AllFlags", want: int64(255), typeName: "Flags"},
+		{name: "AllMask", want: int64(255), typeName: "Mask"},
+		{name: "AllUint32", want: int64(4294967295), typeName: "uint32"},
+		{name: "NotLevel", want: int64(-2), typeName: "Level"},
+		{name: "Untyped", want: int64(-2)},
+		{name: "Shifted", want: int64(1024), typeName: "Level"},
+		{name: "Divided", want: int64(3), typeName: "Level"},
+		{name: "Negative", want: int64(-3), typeName: "Level"},
+		{name: "Name", want: "ab"},
+		{name: "NameLength", want: int64(2), typeName: "Level"},
+		{name: "High", want: int64(20), typeName: "Level"},
+		{name: "Top", want: int64(120), typeName: "Level"},
+		{name: "PriorityUrgent", want: int64(3), typeName: "Priority"}, // declared in other file
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			result, err := r.consts.Eval(&ast.Object{Kind: ast.Con, Name: c.name})
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if got := constValue(result.Value); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("value: want %#v, got %#v", c.want, got)
+			}
+			if result.TypeName != c.typeName {
+				t.Errorf("type: want %q, got %q", c.typeName, result.TypeName)
+			}
+		})
+	}
+}
+
+func TestCandidatesAcrossFiles(t *testing.T) {
+	w, m := loadTestdata(t, "consts")
+	cases := []struct {
+		alias string
+		want  []string
+	}{
+		{alias: "Status", want: []string{"StatusDraft=\"draft\"", "StatusActive=\"active\"", "StatusInactive=\"inactive\""}},
+		{alias: "Priority", want: []string{"PriorityLow=0", "PriorityMid=1", "PriorityHigh=2", "PriorityUrgent=3"}},
+	}
+	for _, c := range cases {
+		t.Run(c.alias, func(t *testing.T) {
+			_, def := m.LookupAlias(c.alias)
+			var got []string
+			for _, v := range def.Candidates {
+				got = append(got, fmt.Sprintf("%s=%v", v.Name, v.Value))
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("candidates of %s\nwant %q\n got %q", c.alias, c.want, got)
+			}
+		})
+	}
+
+	want := []string{"const Orphan: type Unknown is not found in package consts"}
+	if got := messages(w.Diagnostics); !reflect.DeepEqual(got, want) {
+		t.Errorf("diagnostics\nwant %q\n got %q", want, got)
+	}
+}
+
+func TestArrayLenAcrossFiles(t *testing.T) {
+	w, m := loadTestdata(t, "arraylen")
+	if len(w.Diagnostics) > 0 {
+		t.Errorf("unexpected diagnostics %q", messages(w.Diagnostics))
+	}
+	_, digest := m.LookupAlias("Digest")
+	_, block := m.LookupStruct("Block")
+	sums := block.Fields["Sums"].Type.(*ArrayType)
+	cases := []struct {
+		msg     string
+		typ     Type
+		want    int64
+		wantExp string
+	}{
+		{msg: "alias", typ: digest.Original, want: 16, wantExp: "Size"},
+		{msg: "expression", typ: block.Fields["Data"].Type, want: 32, wantExp: "Double"},
+		{msg: "nested", typ: sums.Value, want: 16, wantExp: "Size"},
+	}
+	for _, c := range cases {
+		t.Run(c.msg, func(t *testing.T) {
+			got, ok := c.typ.(*ArrayType)
+			if !ok {
+				t.Fatalf("want array type, but %T", c.typ)
+			}
+			if got.Len != c.want || got.LenExpr != c.wantExp {
+				t.Errorf("want [%s](%d), but [%s](%d)", c.wantExp, c.want, got.LenExpr, got.Len)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package structjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"io"
+	"strings"
+)
+
+// severities of Diagnostic.
+const (
+	SeverityError   = "error"   // broken source, the definition is not collected completely
+	SeverityWarning = "warning" // unsupported or malformed construct
+	SeverityInfo    = "info"    // skipped construct
+)
+
+// Diagnostic is a problem found while collecting definitions.
+type Diagnostic struct {
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	Pos      *Pos   `json:"pos,omitempty"`
+	Node     string `json:"node"` // kind of node (e.g. "Field", "TypeSpec", "Package")
+}
+
+func (d *Diagnostic) String() string {
+	if d.Pos == nil {
+		return fmt.Sprintf("%s: %s", d.Severity, d.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s: %s", d.Pos.Filename, d.Pos.Line, d.Pos.Column, d.Severity, d.Message)
+}
+
+// Diagnostics is a collector of Diagnostic.
+type Diagnostics []*Diagnostic
+
+// Add adds a diagnostic.
+func (ds *Diagnostics) Add(severity string, node string, pos *Pos, message string) {
+	*ds = append(*ds, &Diagnostic{Severity: severity, Message: message, Pos: pos, Node: node})
+}
+
+// Count returns the number of diagnostics with severity.
+func (ds Diagnostics) Count(severity string) int {
+	n := 0
+	for _, d := range ds {
+		if d.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+// WriteText writes diagnostics to w, one per line.
+func (ds Diagnostics) WriteText(w io.Writer) error {
+	for _, d := range ds {
+		if _, err := fmt.Fprintln(w, d.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes diagnostics to w, as a JSON array.
+func (ds Diagnostics) WriteJSON(w io.Writer) error {
+	if ds == nil {
+		ds = Diagnostics{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(ds)
+}
+
+func (r *Result) addDiagnostic(severity string, node ast.Node, message string) {
+	kind := strings.TrimPrefix(fmt.Sprintf("%T", node), "*ast.")
+	var pos *Pos
+	if node != nil {
+		pos = r.findPos(node.Pos(), node.End())
+	}
+	r.Diagnostics.Add(severity, kind, pos, message)
+}
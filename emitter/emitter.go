@@ -0,0 +1,216 @@
+// Package emitter has the helpers shared by the emitters, which render the definitions collected by structjson in other schema languages.
+package emitter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	structjson "github.com/podhmo/go-structjson"
+)
+
+// Definition is a named type emitted by the emitters. either Struct or Alias is not nil.
+type Definition struct {
+	Key    string // key of the definition (e.g. "models.Person")
+	Ref    *structjson.Ref
+	Module *structjson.Module
+	Result *structjson.Result // file declaring the type
+	Struct *structjson.StructDefinition
+	Alias  *structjson.AliasDefinition
+}
+
+// Target is the modules whose definitions are emitted, embedded in the configuration of each emitter.
+type Target struct {
+	// Modules is the full names of the modules. the definitions referenced from them are also emitted.
+	// if empty, World.Roots is used (or all modules, if Roots is also empty).
+	Modules []string
+}
+
+// Modules returns the modules of fullnames. if fullnames is empty, World.Roots is used (or all modules, if Roots is also empty).
+func Modules(w *structjson.World, fullnames []string) ([]*structjson.Module, error) {
+	if len(fullnames) == 0 {
+		fullnames = w.Roots
+	}
+	if len(fullnames) == 0 {
+		for _, m := range w.Modules {
+			fullnames = append(fullnames, m.FullName)
+		}
+		sort.Strings(fullnames)
+	}
+	modules := make([]*structjson.Module, 0, len(fullnames))
+	for _, fullname := range fullnames {
+		m := w.LookupModule(fullname)
+		if m == nil {
+			return nil, fmt.Errorf("module %q is not found", fullname)
+		}
+		modules = append(modules, m)
+	}
+	return modules, nil
+}
+
+// Definitions returns the struct and alias definitions in m, sorted by name.
+func Definitions(m *structjson.Module) []*Definition {
+	var defs []*Definition
+	for _, r := range m.Files {
+		for _, def := range r.StructMap {
+			defs = append(defs, newDefinition(m, r, def.Name, def, nil))
+		}
+		for _, def := range r.AliasMap {
+			defs = append(defs, newDefinition(m, r, def.Name, nil, def))
+		}
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Key < defs[j].Key })
+	return defs
+}
+
+// Queue is the definitions to be emitted, in the order they are found. a definition is pushed only once.
+type Queue struct {
+	defs   []*Definition
+	pushed map[string]bool // by qualified name with the full package name
+}
+
+// Push adds def to the end of q, if not pushed yet.
+func (q *Queue) Push(def *Definition) {
+	key := def.Ref.String()
+	if q.pushed[key] {
+		return
+	}
+	if q.pushed == nil {
+		q.pushed = map[string]bool{}
+	}
+	q.pushed[key] = true
+	q.defs = append(q.defs, def)
+}
+
+// Pop removes the first definition from q and returns it. returns nil if q is empty.
+func (q *Queue) Pop() *Definition {
+	if len(q.defs) == 0 {
+		return nil
+	}
+	def := q.defs[0]
+	q.defs = q.defs[1:]
+	return def
+}
+
+// LookupDefinition returns the definition referred by ref. returns nil if not found in the world.
+func LookupDefinition(ref *structjson.Ref) *Definition {
+	if ref == nil || ref.Module == nil {
+		return nil
+	}
+	if r, def := ref.Module.LookupStruct(ref.Name); def != nil {
+		return newDefinition(ref.Module, r, ref.Name, def, nil)
+	}
+	if r, def := ref.Module.LookupAlias(ref.Name); def != nil {
+		return newDefinition(ref.Module, r, ref.Name, nil, def)
+	}
+	return nil
+}
+
+func newDefinition(m *structjson.Module, r *structjson.Result, name string, s *structjson.StructDefinition, a *structjson.AliasDefinition) *Definition {
+	return &Definition{
+		Key:    m.Name + "." + name,
+		Ref:    &structjson.Ref{Package: m.FullName, Name: name, Module: m},
+		Module: m,
+		Result: r,
+		Struct: s,
+		Alias:  a,
+	}
+}
+
+// Supported reports whether the values of def can be encoded as JSON (func and chan types cannot be).
+func (def *Definition) Supported() bool {
+	if def.Alias == nil {
+		return true
+	}
+	switch def.Alias.Kind {
+	case "func", "channel":
+		return false
+	default:
+		return true
+	}
+}
+
+// Implements reports whether the pointer type of def has the method named name (e.g. "MarshalJSON").
+func (def *Definition) Implements(name string) bool {
+	var methods []*structjson.MethodSetEntry
+	if def.Struct != nil {
+		methods = def.Struct.PointerMethodSet
+	} else {
+		methods = def.Alias.PointerMethodSet
+	}
+	for _, m := range methods {
+		if m.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Doc returns the doc comment of def.
+func (def *Definition) Doc() string {
+	if def.Struct != nil {
+		return def.Struct.Doc
+	}
+	return def.Alias.Doc
+}
+
+// TypeParams returns the type parameters of def.
+func (def *Definition) TypeParams() []*structjson.TypeParam {
+	if def.Struct != nil {
+		return def.Struct.TypeParams
+	}
+	return def.Alias.TypeParams
+}
+
+// SortedFields returns the fields of def in declaration order.
+func SortedFields(def *structjson.StructDefinition) []*structjson.Field {
+	fields := make([]*structjson.Field, 0, len(def.Fields))
+	for _, f := range def.Fields {
+		fields = append(fields, f)
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Index < fields[j].Index })
+	return fields
+}
+
+// Inherits reports whether the embedded field f of def can be emitted as a reference to the embedded struct (e.g. allOf, extends).
+// f must not be a pointer (its fields are absent if it is nil), and all wire fields of embedded must be promoted to def through f
+// (not hidden by conflicts).
+func Inherits(def *structjson.StructDefinition, f *structjson.Field, embedded *structjson.StructDefinition) bool {
+	if _, ok := f.Type.(*structjson.PointerType); ok {
+		return false
+	}
+	for _, ewf := range embedded.WireFields {
+		found := false
+		for _, wf := range def.WireFields {
+			if wf.Name == ewf.Name && wf.Index[0] == f.Index {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// File is a file emitted. Path is slash separated and relative to the output directory.
+type File struct {
+	Path    string
+	Content []byte
+}
+
+// WriteFiles writes files under dir, creating the parent directories.
+func WriteFiles(dir string, files []*File) error {
+	for _, f := range files {
+		path := filepath.Join(dir, filepath.FromSlash(f.Path))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, f.Content, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,676 @@
+// Package graphql emits GraphQL schema (SDL) from the definitions collected by structjson.
+package graphql
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	structjson "github.com/podhmo/go-structjson"
+	"github.com/podhmo/go-structjson/emitter"
+)
+
+// Header is written at the top of the schema.
+const Header = "# Code generated by go-structjson. DO NOT EDIT.\n"
+
+// TagKey is the key of the struct tag overriding the field name (e.g. `graphql:"name"`), or excluding the field (`graphql:"-"`).
+const TagKey = "graphql"
+
+// JSON is the name of the scalar of the values not expressible in GraphQL (e.g. interface{}, map).
+const JSON = "JSON"
+
+// Config is the configuration of Emit.
+type Config struct {
+	emitter.Target
+	// WellKnown is the scalars of the types not emitted as definitions, keyed by the qualified name (e.g. "time.Time").
+	WellKnown map[string]string
+	// Input also emits the input type of each struct (e.g. input PersonInput). the input types referenced from arguments are always emitted.
+	Input bool
+}
+
+// NewConfig returns the default configuration, with DefaultWellKnown.
+func NewConfig() *Config {
+	return &Config{WellKnown: DefaultWellKnown()}
+}
+
+// DefaultWellKnown returns the scalars of emitter.WellKnown.
+// the integers and numbers are JSON, because they may not fit in Int (32bit) or Float.
+func DefaultWellKnown() map[string]string {
+	m := map[string]string{}
+	for name, t := range emitter.WellKnown() {
+		switch {
+		case t.Format == "date-time":
+			m[name] = "Time"
+		case t.Format == "uuid":
+			m[name] = "ID"
+		case t.Type == "string":
+			m[name] = "String"
+		default:
+			m[name] = JSON
+		}
+	}
+	m["gopkg.in/mgo.v2/bson.ObjectId"] = "ID"
+	return m
+}
+
+// Emit emits the schema of the definitions in config.Modules and the definitions referenced from them.
+// structs are emitted as types, the defined types having candidates as enums, and the interfaces having methods as interfaces.
+// generic types are emitted as the JSON scalar.
+func Emit(w *structjson.World, config *Config) ([]byte, error) {
+	if config == nil {
+		config = NewConfig()
+	}
+	e := &gqlEmitter{
+		world:   w,
+		config:  config,
+		decls:   map[string]*decl{},
+		refs:    map[string]*structjson.Ref{},
+		scalars: map[string]bool{},
+	}
+	modules, err := emitter.Modules(w, config.Modules)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range modules {
+		for _, def := range emitter.Definitions(m) {
+			if len(def.TypeParams()) > 0 || !def.Supported() {
+				continue
+			}
+			if def.Struct != nil {
+				e.refType(nil, def.Ref, false)
+				if config.Input {
+					e.refType(nil, def.Ref, true)
+				}
+			} else if isEnum(def.Alias) {
+				e.refType(nil, def.Ref, false)
+			}
+		}
+		for _, iface := range interfaces(m) {
+			e.refType(nil, &structjson.Ref{Package: m.FullName, Name: iface.Name, Module: m}, false)
+		}
+	}
+	e.implements()
+	return e.content(), nil
+}
+
+// interfaces returns the interface definitions in m, sorted by name.
+func interfaces(m *structjson.Module) []*structjson.InterfaceDefinition {
+	var defs []*structjson.InterfaceDefinition
+	for _, r := range m.Files {
+		for _, def := range r.InterfaceMap {
+			if len(def.TypeParams) == 0 && len(def.TypeSet) == 0 {
+				defs = append(defs, def)
+			}
+		}
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+	return defs
+}
+
+type gqlEmitter struct {
+	world   *structjson.World
+	config  *Config
+	decls   map[string]*decl           // keyed by kind and qualified name (e.g. "type models.Person")
+	refs    map[string]*structjson.Ref // named types emitted, keyed by token
+	scalars map[string]bool            // custom scalars used
+}
+
+// decl is a definition in the schema. the names in it are tokens, replaced with the final names (see token).
+type decl struct {
+	kind       string // type, input, enum, interface
+	name       string
+	doc        string
+	fields     []*field
+	values     []*enumValue
+	implements []string
+	def        *emitter.Definition // struct of type
+	methods    []string            // method names of interface
+	empty      bool                // not having any fields, emitted as the JSON scalar
+}
+
+type field struct {
+	name string
+	args []string // e.g. "id: ID!"
+	typ  string
+	doc  string
+}
+
+type enumValue struct {
+	name string
+	doc  string
+}
+
+// token returns the placeholder of the name of the named type. the names are decided after all definitions are emitted,
+// and the types of the same name in different packages are prefixed with the package name (e.g. Models2Person).
+func (e *gqlEmitter) token(ref *structjson.Ref) string {
+	t := "\x00" + ref.String() + "\x00"
+	e.refs[t] = ref
+	return t
+}
+
+// typeExpr returns the GraphQL type of typ, nullable at the outermost. returns "" if not expressible (e.g. func, chan).
+func (e *gqlEmitter) typeExpr(s *emitter.Scope, parent string, name string, typ structjson.Type, input bool) string {
+	switch t := typ.(type) {
+	case *structjson.PrimitiveType:
+		if s.IsParam(t.Value) {
+			return e.scalar(JSON)
+		}
+		if ref := e.world.ResolveRef(s.Module, s.Result, typ); ref != nil {
+			return e.refType(s, ref, input)
+		}
+		switch t.Value {
+		case "string":
+			return "String"
+		case "bool":
+			return "Boolean"
+		case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "uintptr", "byte", "rune":
+			return "Int"
+		case "float32", "float64":
+			return "Float"
+		case "complex64", "complex128":
+			return ""
+		default:
+			return e.scalar(JSON) // any, error
+		}
+	case *structjson.SelectorType:
+		if ref := e.world.ResolveRef(s.Module, s.Result, typ); ref != nil {
+			return e.refType(s, ref, input)
+		}
+		return e.scalar(JSON)
+	case *structjson.PointerType:
+		return e.typeExpr(s, parent, name, t.Value, input)
+	case *structjson.SliceType:
+		if emitter.IsByte(t.Value) {
+			return "String" // base64
+		}
+		return e.listOf(s, parent, name, t.Value, input)
+	case *structjson.ArrayType:
+		return e.listOf(s, parent, name, t.Value, input)
+	case *structjson.StructType:
+		// anonymous struct is emitted as the type named after the field (e.g. ResponseMeta)
+		kind := "type"
+		typeName := parent + name
+		if input {
+			kind = "input"
+			typeName = strings.TrimSuffix(parent, "Input") + name + "Input"
+		}
+		d := &decl{kind: kind, name: typeName}
+		e.decls[kind+" "+typeName] = d
+		for _, f := range t.Fields {
+			if f.JSON == nil || f.JSON.Omitted {
+				continue
+			}
+			doc := emitter.DocOrComment(f.Doc, f.Comment)
+			if item := e.field(s, typeName, f.Name, f.JSON.Name, f.Tags[TagKey], f.Type, f.JSON.OmitEmpty, f.JSON.String, doc, input); item != nil {
+				d.fields = append(d.fields, item)
+			}
+		}
+		if len(d.fields) == 0 {
+			d.empty = true
+			e.world.Diagnostics.Add(structjson.SeverityWarning, "StructType", nil, fmt.Sprintf("the struct of field %s in %s has no fields in GraphQL, emitted as scalar %s", name, s.Module.FullName, JSON))
+			return e.scalar(JSON)
+		}
+		return typeName
+	case *structjson.FuncType, *structjson.ChanType:
+		return ""
+	default:
+		return e.scalar(JSON) // map, interface, generics, etc.
+	}
+}
+
+func (e *gqlEmitter) listOf(s *emitter.Scope, parent string, name string, elem structjson.Type, input bool) string {
+	typ := e.typeExpr(s, parent, name, elem, input)
+	if typ == "" {
+		return ""
+	}
+	if _, ok := elem.(*structjson.PointerType); !ok {
+		typ += "!"
+	}
+	return "[" + typ + "]"
+}
+
+func (e *gqlEmitter) scalar(name string) string {
+	switch name {
+	case "Int", "Float", "String", "Boolean", "ID":
+	default:
+		e.scalars[name] = true
+	}
+	return name
+}
+
+// refType returns the GraphQL type of the named type, emitting the definition of it.
+func (e *gqlEmitter) refType(s *emitter.Scope, ref *structjson.Ref, input bool) string {
+	if name, ok := e.config.WellKnown[ref.String()]; ok {
+		return e.scalar(name)
+	}
+	def := emitter.LookupDefinition(ref)
+	if def == nil {
+		if ref.Module != nil {
+			if r, iface := ref.Module.LookupInterface(ref.Name); iface != nil && !input {
+				return e.interfaceType(ref, r, iface)
+			}
+		}
+		return e.scalar(JSON) // not collected
+	}
+	switch {
+	case len(def.TypeParams()) > 0, def.Implements("MarshalJSON"):
+		return e.scalar(JSON)
+	case def.Implements("MarshalText"):
+		return "String"
+	case def.Struct != nil:
+		return e.structType(def, input)
+	case isEnum(def.Alias):
+		return e.enumType(def)
+	}
+
+	// other defined types are expanded
+	if s == nil {
+		s = &emitter.Scope{Visiting: map[string]bool{}}
+	}
+	expanded := s.Expand(def)
+	if expanded == nil {
+		return e.scalar(JSON) // recursive
+	}
+	defer s.Done(def)
+	return e.typeExpr(expanded, e.token(def.Ref), "", def.Alias.Original, input)
+}
+
+func (e *gqlEmitter) structType(def *emitter.Definition, input bool) string {
+	kind, name := "type", e.token(def.Ref)
+	if input {
+		kind, name = "input", name+"Input"
+	}
+	key := kind + " " + def.Ref.String()
+	if d, ok := e.decls[key]; ok {
+		if d.empty {
+			return e.scalar(JSON)
+		}
+		return name
+	}
+	d := &decl{kind: kind, name: name, doc: def.Doc(), def: def}
+	e.decls[key] = d
+
+	for _, wf := range def.Struct.WireFields {
+		s, origin := emitter.FieldScope(e.world, def, wf)
+		if origin == nil {
+			continue
+		}
+		var tags []string
+		var doc string
+		if f, ok := origin.Fields[wf.Field]; ok {
+			tags = f.Tags[TagKey]
+			doc = emitter.DocOrComment(f.Doc, f.Comment)
+		}
+		// the field promoted through an embedded pointer is absent if it is nil
+		if item := e.field(s, name, wf.Field, wf.Name, tags, wf.Type, wf.OmitEmpty || wf.Indirect, wf.String, doc, input); item != nil {
+			d.fields = append(d.fields, item)
+		}
+	}
+	if len(d.fields) == 0 {
+		// GraphQL has no object type without fields
+		d.empty = true
+		e.world.Diagnostics.Add(structjson.SeverityWarning, "TypeSpec", def.Struct.Pos, fmt.Sprintf("%s has no fields in GraphQL, emitted as scalar %s", def.Ref.String(), JSON))
+		return e.scalar(JSON)
+	}
+	return name
+}
+
+// field returns the field of the struct field, nullable if optional or a pointer. returns nil if excluded by the tag, or not expressible.
+// if quoted (with ",string" option), the number or boolean value is a String.
+func (e *gqlEmitter) field(s *emitter.Scope, parent string, goName string, jsonName string, tags []string, typ structjson.Type, optional bool, quoted bool, doc string, input bool) *field {
+	name := jsonName
+	if len(tags) > 0 && tags[0] != "" {
+		if tags[0] == "-" {
+			return nil
+		}
+		name = tags[0]
+	}
+	if name = identifier(name); name == "" {
+		return nil
+	}
+	t := e.typeExpr(s, parent, goName, typ, input)
+	if t == "" {
+		return nil
+	}
+	if quoted && (t == "Int" || t == "Float" || t == "Boolean") {
+		t = "String"
+	}
+	if _, ok := typ.(*structjson.PointerType); !ok && !optional {
+		t += "!"
+	}
+	return &field{name: name, typ: t, doc: doc}
+}
+
+func (e *gqlEmitter) enumType(def *emitter.Definition) string {
+	name := e.token(def.Ref)
+	key := "enum " + def.Ref.String()
+	if _, ok := e.decls[key]; ok {
+		return name
+	}
+	d := &decl{kind: "enum", name: name, doc: def.Doc()}
+	e.decls[key] = d
+
+	candidates := def.Alias.Candidates
+	// the string values are used as is if possible, otherwise the go names (e.g. ColorRed -> RED)
+	useValue := true
+	for _, c := range candidates {
+		v, ok := c.Computed.(string)
+		if !ok || c.Kind != "string" || identifier(v) != v || v == "true" || v == "false" || v == "null" {
+			useValue = false
+		}
+	}
+	seen := map[string]bool{}
+	for _, c := range candidates {
+		var value string
+		if useValue {
+			value = c.Computed.(string)
+		} else {
+			base := c.Name
+			if strings.HasPrefix(base, def.Ref.Name) && len(base) > len(def.Ref.Name) {
+				base = base[len(def.Ref.Name):]
+			}
+			value = identifier(strings.ToUpper(strings.Join(emitter.Words(base), "_")))
+		}
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		d.values = append(d.values, &enumValue{name: value, doc: emitter.DocOrComment(c.Doc, c.Comment)})
+	}
+	return name
+}
+
+// interfaceType returns the interface of the methods. returns the JSON scalar if no method is expressible as a field.
+func (e *gqlEmitter) interfaceType(ref *structjson.Ref, r *structjson.Result, iface *structjson.InterfaceDefinition) string {
+	name := e.token(ref)
+	key := "interface " + ref.String()
+	if d, ok := e.decls[key]; ok {
+		if d.empty {
+			return e.scalar(JSON)
+		}
+		return name
+	}
+	d := &decl{kind: "interface", name: name, doc: iface.Doc}
+	e.decls[key] = d
+
+	s := &emitter.Scope{Module: ref.Module, Result: r, Visiting: map[string]bool{}}
+	for _, method := range e.interfaceMethods(ref.Module, r, iface, map[*structjson.InterfaceDefinition]bool{}) {
+		d.methods = append(d.methods, method.Name)
+		if item := e.methodField(s, method); item != nil {
+			d.fields = append(d.fields, item)
+		}
+	}
+	if len(d.fields) == 0 {
+		d.empty = true
+		return e.scalar(JSON)
+	}
+	return name
+}
+
+// interfaceMethods returns the exported methods of the interface, including the methods of embedded interfaces, sorted by name.
+func (e *gqlEmitter) interfaceMethods(m *structjson.Module, r *structjson.Result, iface *structjson.InterfaceDefinition, visited map[*structjson.InterfaceDefinition]bool) []*structjson.Method {
+	if visited[iface] {
+		return nil
+	}
+	visited[iface] = true
+	var methods []*structjson.Method
+	for _, method := range iface.Methods {
+		if method.Exported {
+			methods = append(methods, method)
+		}
+	}
+	for _, embed := range iface.Embeds {
+		ref := e.world.ResolveRef(m, r, embed)
+		if ref == nil || ref.Module == nil {
+			continue
+		}
+		if er, embedded := ref.Module.LookupInterface(ref.Name); embedded != nil {
+			methods = append(methods, e.interfaceMethods(ref.Module, er, embedded, visited)...)
+		}
+	}
+	sort.SliceStable(methods, func(i, j int) bool { return methods[i].Name < methods[j].Name })
+	uniq := methods[:0]
+	for i, method := range methods {
+		if i == 0 || methods[i-1].Name != method.Name {
+			uniq = append(uniq, method)
+		}
+	}
+	return uniq
+}
+
+// methodField returns the field of the method, having a result (and an optional error). the params are the arguments, except context.Context.
+func (e *gqlEmitter) methodField(s *emitter.Scope, method *structjson.Method) *field {
+	results := method.Results
+	if len(results) > 0 && isError(results[len(results)-1].Type) {
+		results = results[:len(results)-1]
+	}
+	if len(results) != 1 {
+		return nil
+	}
+	typ := e.typeExpr(s, "", method.Name, results[0].Type, false)
+	if typ == "" {
+		return nil
+	}
+	if _, ok := results[0].Type.(*structjson.PointerType); !ok {
+		typ += "!"
+	}
+
+	item := &field{name: fieldName(method.Name), typ: typ, doc: emitter.DocOrComment(method.Doc, method.Comment)}
+	for i, p := range method.Params {
+		if ref := e.world.ResolveRef(s.Module, s.Result, p.Type); ref != nil && ref.String() == "context.Context" {
+			continue
+		}
+		ptyp := p.Type
+		if ellipsis, ok := ptyp.(*structjson.EllipsisType); ok {
+			ptyp = &structjson.SliceType{Value: ellipsis.Value}
+		}
+		arg := e.typeExpr(s, "", method.Name, ptyp, true)
+		if arg == "" {
+			return nil
+		}
+		if _, ok := ptyp.(*structjson.PointerType); !ok {
+			arg += "!"
+		}
+		name := identifier(p.Name)
+		if name == "" || name == "_" {
+			name = fmt.Sprintf("arg%d", i)
+		}
+		item.args = append(item.args, name+": "+arg)
+	}
+	return item
+}
+
+// implements adds the interfaces to the types having all methods of them (as the method set of the pointer type).
+// the fields of the interfaces not found in the types are added, to be resolved by the methods.
+func (e *gqlEmitter) implements() {
+	var ifaces []*decl
+	for _, d := range e.decls {
+		if d.kind == "interface" && !d.empty {
+			ifaces = append(ifaces, d)
+		}
+	}
+	sort.Slice(ifaces, func(i, j int) bool { return ifaces[i].name < ifaces[j].name })
+	for _, d := range e.decls {
+		if d.kind != "type" || d.def == nil || d.empty {
+			continue
+		}
+		methods := map[string]bool{}
+		for _, m := range d.def.Struct.PointerMethodSet {
+			methods[m.Name] = true
+		}
+		for _, iface := range ifaces {
+			ok := true
+			for _, name := range iface.methods {
+				ok = ok && methods[name]
+			}
+			if !ok {
+				continue
+			}
+			d.implements = append(d.implements, iface.name)
+			for _, f := range iface.fields {
+				found := false
+				for _, x := range d.fields {
+					found = found || x.name == f.name
+				}
+				if !found {
+					d.fields = append(d.fields, f)
+				}
+			}
+		}
+	}
+}
+
+// names returns the names of the named types emitted, by token.
+func (e *gqlEmitter) names() map[string]string {
+	count := map[string]map[string]bool{}
+	for _, ref := range e.refs {
+		if count[ref.Name] == nil {
+			count[ref.Name] = map[string]bool{}
+		}
+		count[ref.Name][ref.Package] = true
+	}
+	tokens := make([]string, 0, len(e.refs))
+	for t := range e.refs {
+		tokens = append(tokens, t)
+	}
+	sort.Strings(tokens)
+	names := map[string]string{}
+	used := map[string]bool{}
+	for _, t := range tokens {
+		ref := e.refs[t]
+		name := ref.Name
+		if len(count[ref.Name]) > 1 && ref.Module != nil {
+			name = title(identifier(ref.Module.Name)) + name // e.g. Models2Person
+		}
+		base := name
+		for i := 2; used[name]; i++ {
+			name = fmt.Sprintf("%s%d", base, i)
+		}
+		used[name] = true
+		names[t] = name
+	}
+	return names
+}
+
+func (e *gqlEmitter) content() []byte {
+	var oldnew []string
+	for t, name := range e.names() {
+		oldnew = append(oldnew, t, name)
+	}
+	replacer := strings.NewReplacer(oldnew...)
+
+	var b strings.Builder
+	b.WriteString(Header)
+
+	scalars := make([]string, 0, len(e.scalars))
+	for name := range e.scalars {
+		scalars = append(scalars, name)
+	}
+	sort.Strings(scalars)
+	for _, name := range scalars {
+		fmt.Fprintf(&b, "\nscalar %s\n", name)
+	}
+
+	type item struct {
+		name string
+		text string
+	}
+	var items []item
+	for _, d := range e.decls {
+		if d.empty {
+			continue
+		}
+		items = append(items, item{name: replacer.Replace(d.name), text: replacer.Replace(d.String())})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].name < items[j].name })
+	for _, it := range items {
+		b.WriteString("\n")
+		b.WriteString(it.text)
+	}
+	return []byte(b.String())
+}
+
+func (d *decl) String() string {
+	var b strings.Builder
+	writeDescription(&b, "", d.doc)
+	fmt.Fprintf(&b, "%s %s", d.kind, d.name)
+	if len(d.implements) > 0 {
+		fmt.Fprintf(&b, " implements %s", strings.Join(d.implements, " & "))
+	}
+	b.WriteString(" {\n")
+	for _, v := range d.values {
+		writeDescription(&b, "  ", v.doc)
+		fmt.Fprintf(&b, "  %s\n", v.name)
+	}
+	for _, f := range d.fields {
+		writeDescription(&b, "  ", f.doc)
+		args := ""
+		if len(f.args) > 0 {
+			args = "(" + strings.Join(f.args, ", ") + ")"
+		}
+		fmt.Fprintf(&b, "  %s%s: %s\n", f.name, args, f.typ)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func writeDescription(b *strings.Builder, indent string, doc string) {
+	doc = strings.TrimSpace(doc)
+	if doc == "" {
+		return
+	}
+	doc = strings.ReplaceAll(doc, `"""`, `\"""`)
+	lines := strings.Split(doc, "\n")
+	if len(lines) == 1 {
+		fmt.Fprintf(b, "%s\"\"\"%s\"\"\"\n", indent, doc)
+		return
+	}
+	fmt.Fprintf(b, "%s\"\"\"\n", indent)
+	for _, line := range lines {
+		fmt.Fprintf(b, "%s%s\n", indent, line)
+	}
+	fmt.Fprintf(b, "%s\"\"\"\n", indent)
+}
+
+// isEnum reports whether the defined type is emitted as enum, having the candidates.
+func isEnum(def *structjson.AliasDefinition) bool {
+	return def != nil && len(def.Candidates) > 0 && len(def.TypeParams) == 0
+}
+
+func isError(typ structjson.Type) bool {
+	t, ok := typ.(*structjson.PrimitiveType)
+	return ok && t.Value == "error" && (t.TypeInfo == nil || t.TypeInfo.Builtin)
+}
+
+var invalid = regexp.MustCompile(`[^_0-9A-Za-z]`)
+
+// identifier returns the name with the characters not allowed in GraphQL names replaced by "_". returns "" if no valid characters.
+func identifier(name string) string {
+	if strings.Trim(invalid.ReplaceAllString(name, ""), "_") == "" {
+		return ""
+	}
+	name = invalid.ReplaceAllString(name, "_")
+	if unicode.IsDigit(rune(name[0])) {
+		name = "_" + name
+	}
+	return name
+}
+
+// fieldName returns the field name of the method (e.g. "FullName" -> "fullName", "ID" -> "id").
+func fieldName(method string) string {
+	ws := emitter.Words(method)
+	if len(ws) == 0 {
+		return method
+	}
+	ws[0] = strings.ToLower(ws[0])
+	return strings.Join(ws, "")
+}
+
+func title(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
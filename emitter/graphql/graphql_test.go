@@ -0,0 +1,75 @@
+package graphql
+
+import (
+	"testing"
+
+	"github.com/podhmo/go-structjson/emitter/internal/golden"
+)
+
+func TestEmitGolden(t *testing.T) {
+	for _, name := range []string{"jsonfields", "rpc"} {
+		t.Run(name, func(t *testing.T) {
+			schema, err := Emit(golden.Load(t, name), NewConfig())
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden.Compare(t, "emit-graphql", "examples/output/graphql/"+name+"/schema.graphql", schema)
+		})
+	}
+}
+
+func TestEmit(t *testing.T) {
+	w := golden.LoadTestdata(t, "tags")
+	// the field renamed and excluded by the tag, quoted with ",string", and resolving the method of the interface
+	user := `"""User :"""
+type User implements Node {
+  fullName: String!
+  age: String!
+  score: String
+  email: String
+  id: String!
+}
+`
+	input := `"""User :"""
+input UserInput {
+  fullName: String!
+  age: String!
+  score: String
+  email: String
+}
+`
+	node := `"""Node :"""
+interface Node {
+  id: String!
+}
+`
+	cases := []struct {
+		msg   string
+		input bool
+		want  string
+	}{
+		{msg: "type", want: Header + "\n" + node + "\n" + user},
+		{msg: "with input", input: true, want: Header + "\n" + node + "\n" + user + "\n" + input},
+	}
+	for _, c := range cases {
+		t.Run(c.msg, func(t *testing.T) {
+			config := NewConfig()
+			config.Input = c.input
+			got, err := Emit(w, config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != c.want {
+				t.Errorf("want:\n%s\ngot:\n%s", c.want, got)
+			}
+		})
+	}
+}
+
+func TestFieldName(t *testing.T) {
+	for method, want := range map[string]string{"FullName": "fullName", "ID": "id", "GroupID": "groupID", "URLPath": "urlPath"} {
+		if got := fieldName(method); got != want {
+			t.Errorf("fieldName(%q): want %q, but %q", method, want, got)
+		}
+	}
+}
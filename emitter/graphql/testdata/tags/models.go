@@ -0,0 +1,18 @@
+package tags
+
+// Node :
+type Node interface {
+	ID() string
+}
+
+// User :
+type User struct {
+	Name     string   `json:"name" graphql:"fullName"`
+	Password string   `json:"password" graphql:"-"`
+	Age      int      `json:"age,string"`
+	Score    *float64 `json:"score,string"`
+	Email    string   `json:"email,omitempty"`
+}
+
+// ID :
+func (u *User) ID() string { return u.Name }
@@ -0,0 +1,79 @@
+// Package golden has the helpers of the golden tests of the emitters, comparing the outputs with the files under examples/output
+// (generated by make emit).
+package golden
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	structjson "github.com/podhmo/go-structjson"
+	"github.com/podhmo/go-structjson/emitter"
+)
+
+// Root is the root directory of the repository, relative to the directories of the emitter packages.
+const Root = "../.."
+
+// Load loads the examples (e.g. "jsonfields") as go-structjson ./examples/<name>/ does.
+func Load(t *testing.T, names ...string) *structjson.World {
+	t.Helper()
+	patterns := make([]string, len(names))
+	for i, name := range names {
+		patterns[i] = "./examples/" + name + "/"
+	}
+	config := structjson.NewConfig(patterns...)
+	config.Dir = Root
+	w, err := structjson.Load(context.Background(), *config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+// LoadTestdata loads the package under testdata of the test (e.g. "./testdata/pinned/").
+func LoadTestdata(t *testing.T, name string) *structjson.World {
+	t.Helper()
+	w, err := structjson.Load(context.Background(), *structjson.NewConfig("./testdata/" + name + "/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+// Compare compares got with the golden file at path, relative to Root. target is the make target generating it (e.g. "emit-jsonschema").
+func Compare(t *testing.T, target string, path string, got []byte) {
+	t.Helper()
+	want, err := os.ReadFile(filepath.Join(Root, filepath.FromSlash(path)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s is different from the output (updated by make %s)\nwant:\n%s\ngot:\n%s", path, target, want, got)
+	}
+}
+
+// CompareJSON compares v encoded as the command does with the golden file at path, like Compare.
+func CompareJSON(t *testing.T, target string, path string, v interface{}) {
+	t.Helper()
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		t.Fatal(err)
+	}
+	Compare(t, target, path, b.Bytes())
+}
+
+// CompareFiles compares the files emitted with the golden files under dir, like Compare.
+func CompareFiles(t *testing.T, target string, dir string, files []*emitter.File) {
+	t.Helper()
+	if len(files) == 0 {
+		t.Fatal("no files are emitted")
+	}
+	for _, f := range files {
+		Compare(t, target, dir+"/"+f.Path, f.Content)
+	}
+}
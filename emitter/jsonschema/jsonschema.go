@@ -0,0 +1,303 @@
+// Package jsonschema emits JSON Schema (draft 2020-12) from the definitions collected by structjson.
+package jsonschema
+
+import (
+	"strings"
+
+	structjson "github.com/podhmo/go-structjson"
+	"github.com/podhmo/go-structjson/emitter"
+)
+
+// DefaultRefPrefix is the default prefix of $ref to the definitions.
+const DefaultRefPrefix = "#/$defs/"
+
+// Config is the configuration of Emit.
+type Config struct {
+	ID string // $id of the document
+
+	emitter.Target
+	// WellKnown is the schemas of the types not emitted as definitions, keyed by the qualified name (e.g. "time.Time").
+	WellKnown map[string]*Schema
+	// RefPrefix is the prefix of $ref to the definitions (e.g. "#/components/schemas/").
+	RefPrefix string
+	// AllOf emits the embedded structs as allOf with $ref, instead of the flattened properties.
+	AllOf bool
+	// OnDefinition is called with each definition and the schema emitted (e.g. for adding extensions).
+	OnDefinition func(def *Definition, s *Schema)
+}
+
+// NewConfig returns the default configuration, with DefaultWellKnown.
+func NewConfig() *Config {
+	return &Config{WellKnown: DefaultWellKnown(), RefPrefix: DefaultRefPrefix}
+}
+
+// Definition is a named type emitted as a schema.
+type Definition = emitter.Definition
+
+// Emit emits the JSON Schema document having the definitions in w as $defs.
+func Emit(w *structjson.World, config *Config) (*Schema, error) {
+	if config == nil {
+		config = NewConfig()
+	}
+	defs, err := EmitDefinitions(w, config)
+	if err != nil {
+		return nil, err
+	}
+	return &Schema{Schema: Draft, ID: config.ID, Defs: defs}, nil
+}
+
+// EmitDefinitions emits the schemas of the definitions in config.Modules and the definitions referenced from them, keyed by Definition.Key.
+// if the package name is shared by other modules in w, the key is qualified with the full package name (e.g. "github.com.podhmo.x.models.Person").
+func EmitDefinitions(w *structjson.World, config *Config) (map[string]*Schema, error) {
+	if config == nil {
+		config = NewConfig()
+	}
+	e := &schemaEmitter{
+		world:  w,
+		config: config,
+		defs:   map[string]*Schema{},
+	}
+	if e.config.RefPrefix == "" {
+		copied := *e.config
+		copied.RefPrefix = DefaultRefPrefix
+		e.config = &copied
+	}
+
+	modules, err := emitter.Modules(w, config.Modules)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range modules {
+		for _, def := range emitter.Definitions(m) {
+			if def.Supported() {
+				e.queue.Push(def)
+			}
+		}
+	}
+	for def := e.queue.Pop(); def != nil; def = e.queue.Pop() {
+		e.defs[e.key(def)] = e.definition(def)
+	}
+	return e.defs, nil
+}
+
+type schemaEmitter struct {
+	world  *structjson.World
+	config *Config
+	defs   map[string]*Schema
+	queue  emitter.Queue
+}
+
+// key returns the key of def in the definitions.
+func (e *schemaEmitter) key(def *Definition) string {
+	for _, m := range e.world.Modules {
+		if m != def.Module && m.Name == def.Module.Name {
+			return strings.ReplaceAll(def.Module.FullName, "/", ".") + "." + def.Ref.Name
+		}
+	}
+	return def.Key
+}
+
+func (e *schemaEmitter) definition(def *Definition) *Schema {
+	var s *Schema
+	switch {
+	case def.Implements("MarshalJSON"):
+		s = &Schema{} // encoded by its own
+	case def.Implements("MarshalText"):
+		s = &Schema{Type: []string{"string"}}
+	case def.Struct != nil:
+		s = e.structSchema(def)
+	default:
+		s = e.aliasSchema(def)
+	}
+	if doc := def.Doc(); doc != "" {
+		s.Description = doc
+	}
+	if e.config.OnDefinition != nil {
+		e.config.OnDefinition(def, s)
+	}
+	return s
+}
+
+func (e *schemaEmitter) structSchema(def *Definition) *Schema {
+	s := &Schema{Type: []string{"object"}}
+	wirefields := def.Struct.WireFields
+	var embeds []*Schema
+	if e.config.AllOf {
+		// the embedded structs not inherited as a whole (conflicts, pointers) are flattened.
+		embedded := map[int]bool{}
+		for _, f := range emitter.SortedFields(def.Struct) {
+			if !f.Embed || f.JSON == nil || !f.JSON.Inline {
+				continue
+			}
+			d := emitter.LookupDefinition(e.world.ResolveRef(def.Module, def.Result, f.Type))
+			if d == nil || d.Struct == nil || !emitter.Inherits(def.Struct, f, d.Struct) {
+				continue
+			}
+			e.queue.Push(d)
+			embeds = append(embeds, &Schema{Ref: e.config.RefPrefix + e.key(d)})
+			embedded[f.Index] = true
+		}
+		wirefields = nil
+		for _, wf := range def.Struct.WireFields {
+			if !embedded[wf.Index[0]] {
+				wirefields = append(wirefields, wf)
+			}
+		}
+	}
+
+	s.Properties = []*Property{}
+	for _, wf := range wirefields {
+		fs, origin := emitter.FieldScope(e.world, def, wf)
+		if origin == nil {
+			continue
+		}
+		ps := e.typeSchema(fs.Module, fs.Result, wf.Type)
+		if ps == nil {
+			continue // not encodable
+		}
+		if wf.String {
+			ps = stringOption(ps)
+		}
+		if f, ok := origin.Fields[wf.Field]; ok {
+			ps.Description = emitter.DocOrComment(f.Doc, f.Comment)
+		}
+		s.Properties = append(s.Properties, &Property{Name: wf.Name, Schema: ps})
+		if !wf.OmitEmpty && !wf.Indirect {
+			s.Required = append(s.Required, wf.Name)
+		}
+	}
+	if len(embeds) > 0 {
+		return &Schema{AllOf: append(embeds, s)}
+	}
+	return s
+}
+
+func (e *schemaEmitter) aliasSchema(def *Definition) *Schema {
+	s := e.typeSchema(def.Module, def.Result, def.Alias.Original)
+	if s == nil {
+		return &Schema{}
+	}
+	for _, c := range def.Alias.Candidates {
+		s.Enum = append(s.Enum, c.Computed)
+	}
+	return s
+}
+
+// typeSchema returns the schema of typ referenced in r of m. returns nil if the values of typ cannot be encoded (e.g. func, chan).
+// the schema returned is a new one, so the caller can modify it.
+func (e *schemaEmitter) typeSchema(m *structjson.Module, r *structjson.Result, typ structjson.Type) *Schema {
+	switch t := typ.(type) {
+	case *structjson.PrimitiveType, *structjson.SelectorType, *structjson.InstantiationType:
+		ref := e.world.ResolveRef(m, r, typ)
+		if ref == nil {
+			if t, ok := typ.(*structjson.PrimitiveType); ok {
+				return builtinSchema(t.Value)
+			}
+			return &Schema{}
+		}
+		return e.refSchema(ref)
+	case *structjson.PointerType:
+		s := e.typeSchema(m, r, t.Value)
+		if s == nil {
+			return nil
+		}
+		return Nullable(s)
+	case *structjson.SliceType:
+		if emitter.IsByte(t.Value) {
+			return &Schema{Type: []string{"string"}, ContentEncoding: "base64"}
+		}
+		items := e.typeSchema(m, r, t.Value)
+		if items == nil {
+			return nil
+		}
+		return &Schema{Type: []string{"array"}, Items: items}
+	case *structjson.ArrayType:
+		items := e.typeSchema(m, r, t.Value)
+		if items == nil {
+			return nil
+		}
+		s := &Schema{Type: []string{"array"}, Items: items}
+		if t.Len >= 0 {
+			n := int(t.Len)
+			s.MinItems, s.MaxItems = &n, &n
+		}
+		return s
+	case *structjson.MapType:
+		value := e.typeSchema(m, r, t.Value)
+		if value == nil {
+			return nil
+		}
+		return &Schema{Type: []string{"object"}, AdditionalProperties: value}
+	case *structjson.StructType:
+		s := &Schema{Type: []string{"object"}, Properties: []*Property{}}
+		for _, f := range t.Fields {
+			if f.JSON == nil || f.JSON.Omitted {
+				continue
+			}
+			ps := e.typeSchema(m, r, f.Type)
+			if ps == nil {
+				continue
+			}
+			if f.JSON.String {
+				ps = stringOption(ps)
+			}
+			s.Properties = append(s.Properties, &Property{Name: f.JSON.Name, Schema: ps})
+			if !f.JSON.OmitEmpty {
+				s.Required = append(s.Required, f.JSON.Name)
+			}
+		}
+		return s
+	case *structjson.FuncType, *structjson.ChanType:
+		return nil
+	default:
+		return &Schema{} // interface, etc.
+	}
+}
+
+func (e *schemaEmitter) refSchema(ref *structjson.Ref) *Schema {
+	if s, ok := e.config.WellKnown[ref.String()]; ok {
+		copied := *s
+		return &copied
+	}
+	def := emitter.LookupDefinition(ref)
+	if def == nil {
+		return &Schema{} // not collected, or interface
+	}
+	if !def.Supported() {
+		return nil
+	}
+	e.queue.Push(def)
+	return &Schema{Ref: e.config.RefPrefix + e.key(def)}
+}
+
+func builtinSchema(name string) *Schema {
+	switch name {
+	case "string":
+		return &Schema{Type: []string{"string"}}
+	case "bool":
+		return &Schema{Type: []string{"boolean"}}
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "uintptr", "byte", "rune":
+		return &Schema{Type: []string{"integer"}}
+	case "float32", "float64":
+		return &Schema{Type: []string{"number"}}
+	case "complex64", "complex128":
+		return nil
+	default:
+		return &Schema{} // any, error
+	}
+}
+
+// stringOption returns the schema of the field with ",string" option, the number or boolean value is encoded as a JSON string.
+func stringOption(s *Schema) *Schema {
+	copied := *s
+	copied.Type = nil
+	for _, typ := range s.Type {
+		switch typ {
+		case "integer", "number", "boolean":
+			typ = "string"
+			copied.Format = ""
+		}
+		copied.Type = append(copied.Type, typ)
+	}
+	return &copied
+}
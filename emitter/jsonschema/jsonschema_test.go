@@ -0,0 +1,55 @@
+package jsonschema
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/podhmo/go-structjson/emitter/internal/golden"
+)
+
+func TestEmitGolden(t *testing.T) {
+	for _, name := range []string{"jsonfields", "rpc"} {
+		t.Run(name, func(t *testing.T) {
+			schema, err := Emit(golden.Load(t, name), NewConfig())
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden.CompareJSON(t, "emit-jsonschema", "examples/output/jsonschema/"+name+".json", schema)
+		})
+	}
+}
+
+func TestParseWellKnown(t *testing.T) {
+	cases := []struct {
+		msg     string
+		input   string
+		want    map[string]*Schema
+		wantErr bool
+	}{
+		{msg: "empty", input: "", want: map[string]*Schema{}},
+		{msg: "type and format", input: "time.Time=string:date-time", want: map[string]*Schema{"time.Time": {Type: []string{"string"}, Format: "date-time"}}},
+		{
+			msg:   "multiple, with full package name",
+			input: "example.com/x.ID=integer, example.com/x.Any=any",
+			want:  map[string]*Schema{"example.com/x.ID": {Type: []string{"integer"}}, "example.com/x.Any": {}},
+		},
+		{msg: "without type", input: "time.Time", wantErr: true},
+	}
+	for _, c := range cases {
+		t.Run(c.msg, func(t *testing.T) {
+			got, err := ParseWellKnown(c.input)
+			if c.wantErr {
+				if err == nil {
+					t.Errorf("want error, but %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("want %+v, but %+v", c.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// Draft is the URI of the JSON Schema dialect emitted.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema. only the keywords used by the emitters are defined.
+type Schema struct {
+	Schema      string   // $schema
+	ID          string   // $id
+	Ref         string   // $ref
+	Title       string   // title
+	Description string   // description
+	Type        []string // type, a string if only one
+	Format      string   // format
+
+	ContentEncoding string        // contentEncoding (e.g. "base64")
+	Enum            []interface{} // enum
+
+	Properties           []*Property // properties, in order
+	Required             []string    // required
+	AdditionalProperties *Schema     // additionalProperties
+	Items                *Schema     // items
+	MinItems             *int        // minItems
+	MaxItems             *int        // maxItems
+
+	AllOf []*Schema // allOf
+	AnyOf []*Schema // anyOf
+
+	Defs map[string]*Schema // $defs
+
+	// Extensions is the keywords not defined in JSON Schema (e.g. "x-go-type"), emitted after the others.
+	Extensions map[string]interface{}
+}
+
+// Property is a named property of an object schema.
+type Property struct {
+	Name   string
+	Schema *Schema
+}
+
+// Property returns the property named name. returns nil if not found.
+func (s *Schema) Property(name string) *Schema {
+	for _, p := range s.Properties {
+		if p.Name == name {
+			return p.Schema
+		}
+	}
+	return nil
+}
+
+// IsEmpty reports whether s accepts any value ({}).
+func (s *Schema) IsEmpty() bool {
+	b, _ := json.Marshal(s)
+	return string(b) == "{}"
+}
+
+// Nullable returns the schema accepting null in addition to the values of s.
+func Nullable(s *Schema) *Schema {
+	switch {
+	case s.IsEmpty():
+		return s
+	case len(s.Type) > 0 && s.Ref == "":
+		for _, typ := range s.Type {
+			if typ == "null" {
+				return s
+			}
+		}
+		copied := *s
+		copied.Type = append(append([]string(nil), s.Type...), "null")
+		if len(s.Enum) > 0 {
+			copied.Enum = append(append([]interface{}(nil), s.Enum...), nil)
+		}
+		return &copied
+	default:
+		return &Schema{AnyOf: []*Schema{s, {Type: []string{"null"}}}}
+	}
+}
+
+type keyValue struct {
+	Key   string
+	Value interface{}
+}
+
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	var kvs []keyValue
+	add := func(key string, value interface{}) {
+		kvs = append(kvs, keyValue{Key: key, Value: value})
+	}
+	if s.Schema != "" {
+		add("$schema", s.Schema)
+	}
+	if s.ID != "" {
+		add("$id", s.ID)
+	}
+	if s.Ref != "" {
+		add("$ref", s.Ref)
+	}
+	if s.Title != "" {
+		add("title", s.Title)
+	}
+	if s.Description != "" {
+		add("description", s.Description)
+	}
+	if len(s.Type) == 1 {
+		add("type", s.Type[0])
+	} else if len(s.Type) > 1 {
+		add("type", s.Type)
+	}
+	if s.Format != "" {
+		add("format", s.Format)
+	}
+	if s.ContentEncoding != "" {
+		add("contentEncoding", s.ContentEncoding)
+	}
+	if len(s.Enum) > 0 {
+		add("enum", s.Enum)
+	}
+	if s.Properties != nil {
+		add("properties", properties(s.Properties))
+	}
+	if len(s.Required) > 0 {
+		add("required", s.Required)
+	}
+	if s.AdditionalProperties != nil {
+		add("additionalProperties", s.AdditionalProperties)
+	}
+	if s.Items != nil {
+		add("items", s.Items)
+	}
+	if s.MinItems != nil {
+		add("minItems", *s.MinItems)
+	}
+	if s.MaxItems != nil {
+		add("maxItems", *s.MaxItems)
+	}
+	if len(s.AllOf) > 0 {
+		add("allOf", s.AllOf)
+	}
+	if len(s.AnyOf) > 0 {
+		add("anyOf", s.AnyOf)
+	}
+	if len(s.Defs) > 0 {
+		add("$defs", s.Defs)
+	}
+	keys := make([]string, 0, len(s.Extensions))
+	for key := range s.Extensions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		add(key, s.Extensions[key])
+	}
+	return marshalObject(kvs)
+}
+
+type properties []*Property
+
+func (ps properties) MarshalJSON() ([]byte, error) {
+	kvs := make([]keyValue, len(ps))
+	for i, p := range ps {
+		kvs[i] = keyValue{Key: p.Name, Value: p.Schema}
+	}
+	return marshalObject(kvs)
+}
+
+// marshalObject encodes kvs as a JSON object, keeping the order.
+func marshalObject(kvs []keyValue) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, kv := range kvs {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(kv.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(kv.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,48 @@
+package jsonschema
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/podhmo/go-structjson/emitter"
+)
+
+// DefaultWellKnown returns the schemas of emitter.WellKnown.
+func DefaultWellKnown() map[string]*Schema {
+	m := map[string]*Schema{}
+	for name, t := range emitter.WellKnown() {
+		s := &Schema{Format: t.Format}
+		if t.Type != "" {
+			s.Type = []string{t.Type}
+		}
+		m[name] = s
+	}
+	return m
+}
+
+// ParseWellKnown parses the mappings of well-known types, in the form of "<package>.<Name>=<type>[:<format>]" separated by comma
+// (e.g. "time.Time=string:date-time,example.com/x.ID=integer").
+func ParseWellKnown(s string) (map[string]*Schema, error) {
+	m := map[string]*Schema{}
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		i := strings.LastIndex(item, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid well-known mapping %q (<package>.<Name>=<type>[:<format>])", item)
+		}
+		name, value := item[:i], item[i+1:]
+		typ, format := value, ""
+		if j := strings.Index(value, ":"); j >= 0 {
+			typ, format = value[:j], value[j+1:]
+		}
+		schema := &Schema{Format: format}
+		if typ != "" && typ != "any" {
+			schema.Type = []string{typ}
+		}
+		m[name] = schema
+	}
+	return m, nil
+}
@@ -0,0 +1,30 @@
+package emitter
+
+import "unicode"
+
+// Words splits the camel case name into words (e.g. "GroupID" -> "Group", "ID").
+func Words(name string) []string {
+	rs := []rune(name)
+	var ws []string
+	start := 0
+	for i := 1; i < len(rs); i++ {
+		prev, cur := rs[i-1], rs[i]
+		switch {
+		case cur == '_':
+			ws = append(ws, string(rs[start:i]))
+			start = i + 1
+		case unicode.IsUpper(cur) && (unicode.IsLower(prev) || unicode.IsDigit(prev)),
+			unicode.IsUpper(cur) && unicode.IsUpper(prev) && i+1 < len(rs) && unicode.IsLower(rs[i+1]):
+			ws = append(ws, string(rs[start:i]))
+			start = i
+		}
+	}
+	ws = append(ws, string(rs[start:]))
+	var r []string
+	for _, w := range ws {
+		if w != "" {
+			r = append(r, w)
+		}
+	}
+	return r
+}
@@ -0,0 +1,103 @@
+// Package openapi emits the components of OpenAPI 3.1 from the definitions collected by structjson.
+package openapi
+
+import (
+	structjson "github.com/podhmo/go-structjson"
+	"github.com/podhmo/go-structjson/emitter"
+	"github.com/podhmo/go-structjson/emitter/jsonschema"
+)
+
+// Version is the version of OpenAPI emitted.
+const Version = "3.1.0"
+
+// RefPrefix is the prefix of $ref to the schemas in components.
+const RefPrefix = "#/components/schemas/"
+
+// Document is an OpenAPI document having only components.
+type Document struct {
+	OpenAPI    string      `json:"openapi"`
+	Info       *Info       `json:"info"`
+	Components *Components `json:"components"`
+}
+
+// Info is the metadata of the API.
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// Components is the reusable objects of the API.
+type Components struct {
+	Schemas map[string]*jsonschema.Schema `json:"schemas"`
+}
+
+// Config is the configuration of Emit.
+type Config struct {
+	Title   string // info.title
+	Version string // info.version
+
+	// JSONSchema is the configuration of the schemas (e.g. Modules, WellKnown). RefPrefix and AllOf are overwritten.
+	JSONSchema *jsonschema.Config
+}
+
+// NewConfig returns the default configuration.
+func NewConfig() *Config {
+	return &Config{
+		Title:      "go-structjson",
+		Version:    "0.0.0",
+		JSONSchema: jsonschema.NewConfig(),
+	}
+}
+
+// Emit emits the OpenAPI document having the definitions in w as components.schemas.
+// each schema has x-go-type and x-go-package, and the schema of enum has x-enum-varnames.
+func Emit(w *structjson.World, config *Config) (*Document, error) {
+	if config == nil {
+		config = NewConfig()
+	}
+	sconfig := jsonschema.NewConfig()
+	if config.JSONSchema != nil {
+		copied := *config.JSONSchema
+		sconfig = &copied
+	}
+	sconfig.RefPrefix = RefPrefix
+	sconfig.AllOf = true
+	hook := sconfig.OnDefinition
+	sconfig.OnDefinition = func(def *emitter.Definition, s *jsonschema.Schema) {
+		extend(def, s)
+		if hook != nil {
+			hook(def, s)
+		}
+	}
+
+	schemas, err := jsonschema.EmitDefinitions(w, sconfig)
+	if err != nil {
+		return nil, err
+	}
+	return &Document{
+		OpenAPI:    Version,
+		Info:       &Info{Title: config.Title, Version: config.Version},
+		Components: &Components{Schemas: schemas},
+	}, nil
+}
+
+// extend adds the extensions pointing back to the go's definition.
+func extend(def *emitter.Definition, s *jsonschema.Schema) {
+	if s.Extensions == nil {
+		s.Extensions = map[string]interface{}{}
+	}
+	s.Extensions["x-go-type"] = def.Ref.Name
+	s.Extensions["x-go-package"] = def.Module.FullName
+	if def.Alias == nil {
+		return
+	}
+	candidates := def.Alias.Candidates
+	if len(candidates) == 0 || len(candidates) != len(s.Enum) {
+		return
+	}
+	names := make([]string, len(candidates))
+	for i, c := range candidates {
+		names[i] = c.Name
+	}
+	s.Extensions["x-enum-varnames"] = names
+}
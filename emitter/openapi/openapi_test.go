@@ -0,0 +1,80 @@
+package openapi
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/podhmo/go-structjson/emitter/internal/golden"
+)
+
+func TestEmitGolden(t *testing.T) {
+	for _, name := range []string{"jsonfields", "rpc"} {
+		t.Run(name, func(t *testing.T) {
+			doc, err := Emit(golden.Load(t, name), NewConfig())
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden.CompareJSON(t, "emit-openapi", "examples/output/openapi/"+name+".json", doc)
+		})
+	}
+}
+
+func TestExtensions(t *testing.T) {
+	doc, err := Emit(golden.Load(t, "enum"), NewConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, ok := doc.Components.Schemas["enum.Status"]
+	if !ok {
+		t.Fatal("enum.Status is not emitted")
+	}
+	if want := []interface{}{int64(1), int64(2), int64(3)}; !reflect.DeepEqual(s.Enum, want) {
+		t.Errorf("enum: want %v, but %v", want, s.Enum)
+	}
+	want := map[string]interface{}{
+		"x-go-type":       "Status",
+		"x-go-package":    "github.com/podhmo/go-structjson/examples/enum",
+		"x-enum-varnames": []string{"StatusActive", "StatusInactive", "StatusDeleted"},
+	}
+	if !reflect.DeepEqual(s.Extensions, want) {
+		t.Errorf("extensions: want %v, but %v", want, s.Extensions)
+	}
+}
+
+func TestAllOf(t *testing.T) {
+	t.Run("embedded struct", func(t *testing.T) {
+		doc, err := Emit(golden.Load(t, "generics"), NewConfig())
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := doc.Components.Schemas["generics.People"]
+		if s == nil || len(s.AllOf) != 2 {
+			t.Fatalf("want allOf of the embedded struct and the own properties, but %+v", s)
+		}
+		if want := RefPrefix + "generics.Page"; s.AllOf[0].Ref != want {
+			t.Errorf("want $ref %q, but %q", want, s.AllOf[0].Ref)
+		}
+	})
+	t.Run("pointer and conflicted embeds are flattened", func(t *testing.T) {
+		doc, err := Emit(golden.Load(t, "jsonfields"), NewConfig())
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := doc.Components.Schemas["jsonfields.Admin"]
+		if s == nil || len(s.AllOf) != 0 {
+			t.Fatalf("want the flattened properties, but %+v", s)
+		}
+		var names []string
+		for _, p := range s.Properties {
+			names = append(names, p.Name)
+		}
+		if want := []string{"id", "updatedAt", "group", "level", "-", "Name", "name"}; !reflect.DeepEqual(names, want) {
+			t.Errorf("properties: want %q, but %q", want, names)
+		}
+		for _, name := range s.Required {
+			if name == "id" {
+				t.Errorf("id through the pointer embed is required")
+			}
+		}
+	})
+}
@@ -0,0 +1,625 @@
+// Package protobuf emits Protocol Buffers (proto3) schema files from the definitions collected by structjson.
+package protobuf
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	structjson "github.com/podhmo/go-structjson"
+	"github.com/podhmo/go-structjson/emitter"
+)
+
+// Header is written at the top of each file.
+const Header = "// Code generated by go-structjson. DO NOT EDIT.\n"
+
+// TagKey is the key of the struct tag pinning the field number (e.g. `protobuf:"bytes,3,opt,name=name"` or `protobuf:"3"`).
+const TagKey = "protobuf"
+
+// WellKnown is the proto type of a go type not emitted as a message.
+type WellKnown struct {
+	Type   string // e.g. "google.protobuf.Timestamp", "string"
+	Import string // file to be imported, if the type is a message (e.g. "google/protobuf/timestamp.proto")
+}
+
+// Config is the configuration of Emit.
+type Config struct {
+	emitter.Target
+	// WellKnown is the proto types of the types not emitted as messages, keyed by the qualified name (e.g. "time.Time").
+	WellKnown map[string]*WellKnown
+}
+
+// NewConfig returns the default configuration, with DefaultWellKnown.
+func NewConfig() *Config {
+	return &Config{WellKnown: DefaultWellKnown()}
+}
+
+// DefaultWellKnown returns the proto types of emitter.WellKnown.
+func DefaultWellKnown() map[string]*WellKnown {
+	m := map[string]*WellKnown{}
+	for name, t := range emitter.WellKnown() {
+		switch {
+		case t.Format == "date-time":
+			m[name] = &WellKnown{Type: "google.protobuf.Timestamp", Import: "google/protobuf/timestamp.proto"}
+		case t.Format == "duration":
+			m[name] = &WellKnown{Type: "google.protobuf.Duration", Import: "google/protobuf/duration.proto"}
+		case t.Type == "":
+			m[name] = value
+		default:
+			m[name] = &WellKnown{Type: "string"}
+		}
+	}
+	m["time.Duration"] = &WellKnown{Type: "int64"} // nanoseconds, the others of integer and number are arbitrary precision
+	return m
+}
+
+// value is the type of dynamic values (e.g. interface{}).
+var value = &WellKnown{Type: "google.protobuf.Value", Import: "google/protobuf/struct.proto"}
+
+// empty is the type of the struct without fields (e.g. struct{}).
+var empty = &WellKnown{Type: "google.protobuf.Empty", Import: "google/protobuf/empty.proto"}
+
+// Package returns the proto package of the module (e.g. "github_com.foo.models" for "github.com/foo/models").
+func Package(fullname string) string {
+	parts := strings.Split(fullname, "/")
+	for i, part := range parts {
+		parts[i] = identifier(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+// Path returns the path of the file of the module (e.g. "github.com/foo/models/models.proto").
+func Path(m *structjson.Module) string {
+	return m.FullName + "/" + m.Name + ".proto"
+}
+
+// Emit emits the proto files of the definitions in config.Modules and the definitions referenced from them, one file per module.
+// structs are emitted as messages, and the defined types having candidates as enums. generic types are not supported.
+func Emit(w *structjson.World, config *Config) ([]*emitter.File, error) {
+	if config == nil {
+		config = NewConfig()
+	}
+	e := &protoEmitter{
+		world:  w,
+		config: config,
+		files:  map[string]*file{},
+	}
+	modules, err := emitter.Modules(w, config.Modules)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range modules {
+		e.file(m)
+		for _, def := range emitter.Definitions(m) {
+			if len(def.TypeParams()) > 0 {
+				continue
+			}
+			if def.Struct != nil || isEnum(def.Alias) {
+				e.queue.Push(def)
+			}
+		}
+	}
+	for def := e.queue.Pop(); def != nil; def = e.queue.Pop() {
+		f := e.file(def.Module)
+		s := &scope{Scope: emitter.NewScope(def), file: f}
+		var b strings.Builder
+		writeComment(&b, "", def.Doc())
+		if def.Struct != nil {
+			e.writeMessage(&b, s, "", def.Ref.Name, e.structFields(def))
+		} else {
+			writeEnum(&b, def.Ref.Name, def.Alias)
+		}
+		f.decls[def.Ref.Name] = b.String()
+	}
+
+	paths := make([]string, 0, len(e.files))
+	for p := range e.files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	files := make([]*emitter.File, len(paths))
+	for i, p := range paths {
+		files[i] = &emitter.File{Path: p, Content: e.files[p].content()}
+	}
+	return files, nil
+}
+
+type protoEmitter struct {
+	world  *structjson.World
+	config *Config
+	files  map[string]*file // keyed by path
+	queue  emitter.Queue
+}
+
+// file is a proto file of a module.
+type file struct {
+	path     string
+	module   *structjson.Module
+	imports  map[string]bool
+	decls    map[string]string // declarations by name
+	declared map[string]bool   // names of the messages and enums possibly declared, not to be shadowed by nested messages
+}
+
+func (e *protoEmitter) file(m *structjson.Module) *file {
+	p := Path(m)
+	if f, ok := e.files[p]; ok {
+		return f
+	}
+	f := &file{path: p, module: m, imports: map[string]bool{}, decls: map[string]string{}, declared: map[string]bool{}}
+	for _, def := range emitter.Definitions(m) {
+		f.declared[def.Ref.Name] = true
+	}
+	e.files[p] = f
+	return f
+}
+
+func (f *file) content() []byte {
+	var b strings.Builder
+	b.WriteString(Header)
+	b.WriteString("\nsyntax = \"proto3\";\n")
+	fmt.Fprintf(&b, "\npackage %s;\n", Package(f.module.FullName))
+
+	imports := make([]string, 0, len(f.imports))
+	for p := range f.imports {
+		imports = append(imports, p)
+	}
+	sort.Strings(imports)
+	if len(imports) > 0 {
+		b.WriteString("\n")
+	}
+	for _, p := range imports {
+		fmt.Fprintf(&b, "import %q;\n", p)
+	}
+	fmt.Fprintf(&b, "\noption go_package = %q;\n", f.module.FullName)
+
+	names := make([]string, 0, len(f.decls))
+	for name := range f.decls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b.WriteString("\n")
+		b.WriteString(f.decls[name])
+	}
+	return []byte(b.String())
+}
+
+// scope is the context of a type expression, in the file.
+type scope struct {
+	*emitter.Scope
+	file *file
+}
+
+// field is a field of a message.
+type field struct {
+	name   string // go field name
+	json   string // json name
+	typ    structjson.Type
+	scope  *scope
+	tags   []string // options of TagKey
+	doc    string
+	pos    *structjson.Pos
+	number int
+}
+
+// structFields returns the fields of the message of def, from the wire fields in source order.
+func (e *protoEmitter) structFields(def *emitter.Definition) []*field {
+	var fields []*field
+	for _, wf := range def.Struct.WireFields {
+		fscope, origin := emitter.FieldScope(e.world, def, wf)
+		if origin == nil {
+			continue
+		}
+		item := &field{name: wf.Field, json: wf.Name, typ: wf.Type, scope: &scope{Scope: fscope, file: e.file(def.Module)}}
+		if f, ok := origin.Fields[wf.Field]; ok {
+			item.tags = f.Tags[TagKey]
+			item.pos = f.Pos
+			item.doc = emitter.DocOrComment(f.Doc, f.Comment)
+		}
+		fields = append(fields, item)
+	}
+	return fields
+}
+
+func (e *protoEmitter) writeMessage(b *strings.Builder, s *scope, indent string, name string, fields []*field) {
+	e.numberFields(name, fields)
+	used := map[string]bool{}
+	nested := map[string]bool{} // names of the nested messages
+	fmt.Fprintf(b, "%smessage %s {\n", indent, name)
+	for _, f := range fields {
+		writeComment(b, indent+"  ", f.doc)
+		fieldName := snakeCase(f.name)
+		for _, opt := range f.tags {
+			if strings.HasPrefix(opt, "name=") {
+				fieldName = strings.TrimPrefix(opt, "name=")
+			}
+		}
+		if used[fieldName] {
+			fieldName = fmt.Sprintf("%s_%d", fieldName, f.number) // e.g. Name and NAME
+		}
+		used[fieldName] = true
+		var options string
+		if f.json != jsonName(fieldName) {
+			options = fmt.Sprintf(" [json_name = %q]", f.json)
+		}
+
+		typ := e.fieldType(f.scope, f.typ)
+		if typ == nil {
+			fmt.Fprintf(b, "%s  reserved %d; // %s is not supported\n", indent, f.number, f.name)
+			continue
+		}
+		if typ.nested != nil {
+			copied := *typ
+			copied.name = nestedName(f.scope.file, f.name, nested)
+			typ = &copied
+			e.writeMessage(b, f.scope, indent+"  ", typ.name, typ.nested)
+		}
+		label := ""
+		switch {
+		case typ.repeated:
+			label = "repeated "
+		case typ.optional:
+			label = "optional "
+		}
+		fmt.Fprintf(b, "%s  %s%s %s = %d%s;\n", indent, label, typ.name, fieldName, f.number, options)
+	}
+	fmt.Fprintf(b, "%s}\n", indent)
+}
+
+// nestedName returns the name of the nested message of the field (e.g. MetaMessage), not shadowing the messages and enums declared in the file,
+// nor colliding with the other nested messages in used.
+func nestedName(f *file, field string, used map[string]bool) string {
+	base := field + "Message"
+	name := base
+	for i := 2; f.declared[name] || used[name]; i++ {
+		name = base + strconv.Itoa(i)
+	}
+	used[name] = true
+	return name
+}
+
+// numberFields assigns the field numbers in source order, skipping the numbers pinned through the tag.
+// an invalid pinned number (duplicated, reserved or out of range) is reported to World.Diagnostics, and the field is numbered automatically.
+func (e *protoEmitter) numberFields(message string, fields []*field) {
+	used := map[int]bool{}
+	for _, f := range fields {
+		f.number = 0
+		for _, opt := range f.tags {
+			n, err := strconv.Atoi(opt)
+			if err != nil || n <= 0 {
+				continue
+			}
+			var problem string
+			switch {
+			case used[n]:
+				problem = "is already used"
+			case isReserved(n):
+				problem = "is reserved for the implementation of protobuf"
+			case n > maxFieldNumber:
+				problem = fmt.Sprintf("is greater than %d", maxFieldNumber)
+			}
+			if problem != "" {
+				e.world.Diagnostics.Add(structjson.SeverityWarning, "Field", f.pos, fmt.Sprintf("%s.%s: field number %d %s, numbered automatically", message, f.name, n, problem))
+				break
+			}
+			f.number = n
+			used[n] = true
+			break
+		}
+	}
+	next := 1
+	for _, f := range fields {
+		if f.number > 0 {
+			continue
+		}
+		for used[next] || isReserved(next) {
+			next++
+		}
+		f.number = next
+		used[next] = true
+	}
+}
+
+// maxFieldNumber is the maximum of the field numbers.
+const maxFieldNumber = 1<<29 - 1
+
+// isReserved reports whether the field number n is reserved for the implementation of protobuf.
+func isReserved(n int) bool {
+	return 19000 <= n && n <= 19999
+}
+
+// fieldType is the type of a field.
+type fieldType struct {
+	name     string // e.g. "string", "map<string, int64>", "models.Person"
+	repeated bool
+	optional bool     // with explicit presence (pointer to scalar)
+	message  bool     // message or map, presence is implicit
+	nested   []*field // fields of the nested message, for anonymous struct
+}
+
+// fieldType returns the proto type of typ. returns nil if not supported (e.g. func, nested slices).
+func (e *protoEmitter) fieldType(s *scope, typ structjson.Type) *fieldType {
+	switch t := typ.(type) {
+	case *structjson.PrimitiveType:
+		if s.IsParam(t.Value) {
+			return nil
+		}
+		if ref := e.world.ResolveRef(s.Module, s.Result, typ); ref != nil {
+			return e.refType(s, ref)
+		}
+		switch name := scalar(t.Value); name {
+		case "":
+			return nil
+		case value.Type:
+			s.file.imports[value.Import] = true
+			return &fieldType{name: name, message: true}
+		default:
+			return &fieldType{name: name}
+		}
+	case *structjson.SelectorType:
+		if ref := e.world.ResolveRef(s.Module, s.Result, typ); ref != nil {
+			return e.refType(s, ref)
+		}
+		return nil
+	case *structjson.InstantiationType:
+		return nil // generics
+	case *structjson.PointerType:
+		ft := e.fieldType(s, t.Value)
+		if ft == nil || ft.repeated || ft.message || ft.optional {
+			return ft
+		}
+		copied := *ft
+		copied.optional = true
+		return &copied
+	case *structjson.SliceType:
+		if emitter.IsByte(t.Value) {
+			return &fieldType{name: "bytes"}
+		}
+		return repeated(e.fieldType(s, t.Value))
+	case *structjson.ArrayType:
+		if emitter.IsByte(t.Value) {
+			return &fieldType{name: "bytes"}
+		}
+		return repeated(e.fieldType(s, t.Value))
+	case *structjson.MapType:
+		key := e.fieldType(s, t.Key)
+		val := e.fieldType(s, t.Value)
+		if key == nil || !isKeyType(key.name) || val == nil || val.repeated || val.nested != nil || strings.HasPrefix(val.name, "map<") {
+			return nil
+		}
+		return &fieldType{name: fmt.Sprintf("map<%s, %s>", key.name, val.name), message: true}
+	case *structjson.StructType:
+		var fields []*field
+		for _, f := range t.Fields {
+			if f.JSON == nil || f.JSON.Omitted {
+				continue
+			}
+			fields = append(fields, &field{name: f.Name, json: f.JSON.Name, typ: f.Type, scope: s, tags: f.Tags[TagKey], doc: emitter.DocOrComment(f.Doc, f.Comment), pos: f.Pos})
+		}
+		if len(fields) == 0 {
+			s.file.imports[empty.Import] = true
+			return &fieldType{name: empty.Type, message: true}
+		}
+		return &fieldType{name: "", message: true, nested: fields}
+	case *structjson.InterfaceType:
+		s.file.imports[value.Import] = true
+		return &fieldType{name: value.Type, message: true}
+	default:
+		return nil // func, chan, etc.
+	}
+}
+
+func (e *protoEmitter) refType(s *scope, ref *structjson.Ref) *fieldType {
+	if wk, ok := e.config.WellKnown[ref.String()]; ok {
+		if wk.Import != "" {
+			s.file.imports[wk.Import] = true
+		}
+		return &fieldType{name: wk.Type, message: wk.Import != ""}
+	}
+	def := emitter.LookupDefinition(ref)
+	if def == nil {
+		// not collected, or interface
+		s.file.imports[value.Import] = true
+		return &fieldType{name: value.Type, message: true}
+	}
+	if len(def.TypeParams()) > 0 {
+		return nil
+	}
+	if def.Struct != nil || isEnum(def.Alias) {
+		e.queue.Push(def)
+		name := def.Ref.Name
+		if def.Module != s.file.module {
+			s.file.imports[Path(def.Module)] = true
+			name = Package(def.Module.FullName) + "." + name
+		}
+		return &fieldType{name: name, message: def.Struct != nil}
+	}
+
+	// other defined types are expanded
+	expanded := s.Expand(def)
+	if expanded == nil {
+		return nil // recursive
+	}
+	defer s.Done(def)
+	return e.fieldType(&scope{Scope: expanded, file: s.file}, def.Alias.Original)
+}
+
+func repeated(ft *fieldType) *fieldType {
+	if ft == nil || ft.repeated || strings.HasPrefix(ft.name, "map<") {
+		return nil // nested repeated, repeated map
+	}
+	copied := *ft
+	copied.repeated = true
+	copied.optional = false
+	return &copied
+}
+
+// scalar returns the proto type of the builtin type. returns "" if not supported.
+func scalar(name string) string {
+	switch name {
+	case "string":
+		return "string"
+	case "bool":
+		return "bool"
+	case "int", "int64":
+		return "int64"
+	case "int8", "int16", "int32", "rune":
+		return "int32"
+	case "uint", "uint64", "uintptr":
+		return "uint64"
+	case "uint8", "uint16", "uint32", "byte":
+		return "uint32"
+	case "float32":
+		return "float"
+	case "float64":
+		return "double"
+	case "complex64", "complex128":
+		return ""
+	default:
+		return value.Type // any, error
+	}
+}
+
+func isKeyType(name string) bool {
+	switch name {
+	case "string", "bool", "int32", "int64", "uint32", "uint64", "sint32", "sint64", "fixed32", "fixed64", "sfixed32", "sfixed64":
+		return true
+	default:
+		return false
+	}
+}
+
+// isEnum reports whether the defined type is emitted as enum, having the candidates of int or string.
+func isEnum(def *structjson.AliasDefinition) bool {
+	if def == nil || len(def.Candidates) == 0 || len(def.TypeParams) > 0 {
+		return false
+	}
+	kind := def.Candidates[0].Kind
+	for _, c := range def.Candidates {
+		if c.Kind != kind {
+			return false
+		}
+		if kind == "int" {
+			if _, ok := enumNumber(c); !ok {
+				return false
+			}
+		}
+	}
+	return kind == "int" || kind == "string"
+}
+
+// enumNumber returns the number of the int candidate, if it fits in int32.
+func enumNumber(c *structjson.AliasValue) (int, bool) {
+	var n float64
+	switch v := c.Computed.(type) {
+	case int64:
+		n = float64(v)
+	case uint64:
+		n = float64(v)
+	case float64: // decoded from JSON
+		n = v
+	default:
+		return 0, false
+	}
+	if n < math.MinInt32 || n > math.MaxInt32 || n != math.Trunc(n) {
+		return 0, false
+	}
+	return int(n), true
+}
+
+// writeEnum writes the enum of the candidates. the zero value is added as <NAME>_UNSPECIFIED if not found,
+// and the numbers of string candidates are assigned in declaration order.
+func writeEnum(b *strings.Builder, name string, def *structjson.AliasDefinition) {
+	type enumValue struct {
+		name   string
+		number int
+		doc    string
+	}
+	prefix := upperSnakeCase(name) + "_"
+	var values []*enumValue
+	hasZero, hasAlias := false, false
+	used := map[int]bool{}
+	for i, c := range def.Candidates {
+		// enum values are scoped by the package, not by the enum (e.g. PersonGenderFemale -> PERSON_GENDER_FEMALE)
+		base := c.Name
+		if strings.HasPrefix(base, name) && len(base) > len(name) {
+			base = base[len(name):]
+		}
+		v := &enumValue{name: prefix + upperSnakeCase(base), number: i + 1, doc: emitter.DocOrComment(c.Doc, c.Comment)}
+		if c.Kind == "int" {
+			v.number, _ = enumNumber(c)
+		}
+		hasZero = hasZero || v.number == 0
+		hasAlias = hasAlias || used[v.number]
+		used[v.number] = true
+		values = append(values, v)
+	}
+	if !hasZero {
+		values = append([]*enumValue{{name: prefix + "UNSPECIFIED"}}, values...)
+	}
+	// the first value must be zero
+	sort.SliceStable(values, func(i, j int) bool { return values[i].number == 0 && values[j].number != 0 })
+
+	fmt.Fprintf(b, "enum %s {\n", name)
+	if hasAlias {
+		b.WriteString("  option allow_alias = true;\n")
+	}
+	for _, v := range values {
+		writeComment(b, "  ", v.doc)
+		fmt.Fprintf(b, "  %s = %d;\n", v.name, v.number)
+	}
+	b.WriteString("}\n")
+}
+
+func writeComment(b *strings.Builder, indent string, doc string) {
+	doc = strings.TrimSpace(doc)
+	if doc == "" {
+		return
+	}
+	for _, line := range strings.Split(doc, "\n") {
+		fmt.Fprintf(b, "%s// %s\n", indent, line)
+	}
+}
+
+// identifier returns s with the characters not allowed in identifiers replaced by "_".
+func identifier(s string) string {
+	rs := []rune(s)
+	for i, r := range rs {
+		if !(r == '_' || r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))) {
+			rs[i] = '_'
+		}
+	}
+	if len(rs) == 0 || unicode.IsDigit(rs[0]) {
+		return "_" + string(rs)
+	}
+	return string(rs)
+}
+
+func snakeCase(name string) string {
+	return identifier(strings.ToLower(strings.Join(emitter.Words(name), "_")))
+}
+
+func upperSnakeCase(name string) string {
+	return identifier(strings.ToUpper(strings.Join(emitter.Words(name), "_")))
+}
+
+// jsonName returns the default json name of the proto field (e.g. "group_id" -> "groupId").
+func jsonName(name string) string {
+	var b strings.Builder
+	upper := false
+	for _, r := range name {
+		switch {
+		case r == '_':
+			upper = true
+		case upper:
+			b.WriteRune(unicode.ToUpper(r))
+			upper = false
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
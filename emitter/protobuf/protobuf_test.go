@@ -0,0 +1,81 @@
+package protobuf
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/podhmo/go-structjson/emitter/internal/golden"
+)
+
+func TestEmitGolden(t *testing.T) {
+	files, err := Emit(golden.Load(t, "jsonfields", "rpc"), NewConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden.CompareFiles(t, "emit-protobuf", "examples/output/protobuf", files)
+}
+
+func TestPinned(t *testing.T) {
+	w := golden.LoadTestdata(t, "pinned")
+	files, err := Emit(w, NewConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("want 1 file, but %d", len(files))
+	}
+	content := string(files[0].Content)
+
+	t.Run("field numbers", func(t *testing.T) {
+		for _, want := range []string{
+			"string name = 2;", // pinned
+			"string id = 1;",
+			"int64 code = 3;",
+			"int64 big = 4;",
+			"string note = 5;",
+		} {
+			if !strings.Contains(content, want) {
+				t.Errorf("want %q in\n%s", want, content)
+			}
+		}
+	})
+	t.Run("diagnostics", func(t *testing.T) {
+		var got []string
+		for _, d := range w.Diagnostics {
+			got = append(got, d.Message)
+		}
+		want := []string{
+			"Pinned.ID: field number 2 is already used, numbered automatically",
+			"Pinned.Code: field number 19000 is reserved for the implementation of protobuf, numbered automatically",
+			"Pinned.Big: field number 536870912 is greater than 536870911, numbered automatically",
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("want %q, but %q", want, got)
+		}
+	})
+	t.Run("anonymous structs", func(t *testing.T) {
+		for _, want := range []string{
+			"  message MetaMessage {\n    int64 count = 1;\n  }\n  MetaMessage meta = 6;\n", // not shadowing Meta
+			"  Meta other = 8;\n",
+			"  google.protobuf.Empty empty = 7;\n",
+			"import \"google/protobuf/empty.proto\";\n",
+		} {
+			if !strings.Contains(content, want) {
+				t.Errorf("want %q in\n%s", want, content)
+			}
+		}
+	})
+}
+
+func TestNestedName(t *testing.T) {
+	f := &file{declared: map[string]bool{"Meta": true, "ItemMessage": true}}
+	used := map[string]bool{}
+	var got []string
+	for _, field := range []string{"Meta", "Item", "Meta", "Item"} {
+		got = append(got, nestedName(f, field, used))
+	}
+	if want := []string{"MetaMessage", "ItemMessage2", "MetaMessage2", "ItemMessage3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %q, but %q", want, got)
+	}
+}
@@ -0,0 +1,20 @@
+package pinned
+
+// Meta :
+type Meta struct {
+	Count int `json:"count"`
+}
+
+// Pinned :
+type Pinned struct {
+	Name string `json:"name" protobuf:"bytes,2,opt,name=name"`
+	ID   string `json:"id" protobuf:"2"`
+	Code int    `json:"code" protobuf:"19000"`
+	Big  int    `json:"big" protobuf:"536870912"`
+	Note string `json:"note"`
+	Meta struct {
+		Count int `json:"count"`
+	} `json:"meta"`
+	Empty struct{} `json:"empty"`
+	Other Meta     `json:"other"`
+}
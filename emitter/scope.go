@@ -0,0 +1,63 @@
+package emitter
+
+import structjson "github.com/podhmo/go-structjson"
+
+// Scope is the context of a type expression, in which the names in it are resolved.
+type Scope struct {
+	Module   *structjson.Module
+	Result   *structjson.Result      // file in which the expression is written
+	Params   []*structjson.TypeParam // type parameters, not substituted
+	Visiting map[string]bool         // defined types being expanded (see Expand)
+}
+
+// NewScope returns the scope of the type expressions in def.
+func NewScope(def *Definition) *Scope {
+	return &Scope{Module: def.Module, Result: def.Result, Params: def.TypeParams(), Visiting: map[string]bool{}}
+}
+
+// FieldScope returns the scope of the type of the wire field wf of def, and the struct declaring the field.
+// if wf is promoted through embedded structs, they are of the embedded struct declaring it. returns nil if it is not found.
+func FieldScope(w *structjson.World, def *Definition, wf *structjson.WireField) (*Scope, *structjson.StructDefinition) {
+	if len(wf.Index) <= 1 {
+		return NewScope(def), def.Struct
+	}
+	m, r, origin := w.LookupOrigin(wf.Origin)
+	if origin == nil {
+		return nil, nil
+	}
+	return &Scope{Module: m, Result: r, Params: origin.TypeParams, Visiting: map[string]bool{}}, origin
+}
+
+// IsParam reports whether name is a type parameter in s.
+func (s *Scope) IsParam(name string) bool {
+	for _, tp := range s.Params {
+		if tp.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Expand returns the scope of the underlying type of the defined type def, referenced in s.
+// returns nil if def is already being expanded (recursive). Done must be called after the expansion.
+func (s *Scope) Expand(def *Definition) *Scope {
+	key := def.Ref.String()
+	if s.Visiting[key] {
+		return nil
+	}
+	s.Visiting[key] = true
+	return &Scope{Module: def.Module, Result: def.Result, Params: def.TypeParams(), Visiting: s.Visiting}
+}
+
+// Done ends the expansion of def started by Expand.
+func (s *Scope) Done(def *Definition) {
+	delete(s.Visiting, def.Ref.String())
+}
+
+// DocOrComment returns doc, or comment if doc is empty (e.g. of a field, a const).
+func DocOrComment(doc, comment string) string {
+	if doc == "" {
+		return comment
+	}
+	return doc
+}
@@ -0,0 +1,488 @@
+// Package typescript emits TypeScript declaration files (.d.ts) from the definitions collected by structjson.
+package typescript
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	structjson "github.com/podhmo/go-structjson"
+	"github.com/podhmo/go-structjson/emitter"
+)
+
+// Header is written at the top of each file.
+const Header = "// Code generated by go-structjson. DO NOT EDIT.\n"
+
+// Config is the configuration of Emit.
+type Config struct {
+	emitter.Target
+	// WellKnown is the TypeScript types of the types not emitted as definitions, keyed by the qualified name (e.g. "time.Time").
+	WellKnown map[string]string
+	// ConstEnum emits the candidates as const enum, instead of the union of literal types.
+	ConstEnum bool
+}
+
+// NewConfig returns the default configuration, with DefaultWellKnown.
+func NewConfig() *Config {
+	return &Config{WellKnown: DefaultWellKnown()}
+}
+
+// DefaultWellKnown returns the TypeScript types of emitter.WellKnown.
+func DefaultWellKnown() map[string]string {
+	m := map[string]string{}
+	for name, t := range emitter.WellKnown() {
+		switch t.Type {
+		case "string":
+			m[name] = "string"
+		case "integer", "number":
+			m[name] = "number"
+		default:
+			m[name] = "unknown"
+		}
+	}
+	return m
+}
+
+// Path returns the path of the file of the module (e.g. "github.com/foo/models.d.ts").
+func Path(fullname string) string {
+	return fullname + ".d.ts"
+}
+
+// Emit emits the declaration files of the definitions in config.Modules and the definitions referenced from them, one file per module.
+func Emit(w *structjson.World, config *Config) ([]*emitter.File, error) {
+	if config == nil {
+		config = NewConfig()
+	}
+	e := &tsEmitter{
+		world:  w,
+		config: config,
+		files:  map[string]*file{},
+	}
+	modules, err := emitter.Modules(w, config.Modules)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range modules {
+		e.file(m)
+		for _, def := range emitter.Definitions(m) {
+			if def.Supported() {
+				e.queue.Push(def)
+			}
+		}
+	}
+	for def := e.queue.Pop(); def != nil; def = e.queue.Pop() {
+		f := e.file(def.Module)
+		f.decls[def.Ref.Name] = e.definition(f, def)
+	}
+
+	paths := make([]string, 0, len(e.files))
+	for p := range e.files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	files := make([]*emitter.File, len(paths))
+	for i, p := range paths {
+		files[i] = &emitter.File{Path: p, Content: e.files[p].content()}
+	}
+	return files, nil
+}
+
+type tsEmitter struct {
+	world  *structjson.World
+	config *Config
+	files  map[string]*file // keyed by path
+	queue  emitter.Queue
+}
+
+// file is a declaration file of a module.
+type file struct {
+	path    string
+	module  *structjson.Module
+	imports map[string]string // namespace by full name of module
+	used    map[string]bool   // namespaces
+	decls   map[string]string // declarations by name
+}
+
+func (e *tsEmitter) file(m *structjson.Module) *file {
+	p := Path(m.FullName)
+	if f, ok := e.files[p]; ok {
+		return f
+	}
+	f := &file{path: p, module: m, imports: map[string]string{}, used: map[string]bool{}, decls: map[string]string{}}
+	e.files[p] = f
+	return f
+}
+
+// namespace returns the name of the namespace import of m (e.g. import type * as models from "./models").
+func (f *file) namespace(m *structjson.Module) string {
+	if ns, ok := f.imports[m.FullName]; ok {
+		return ns
+	}
+	base := m.Name
+	if !identifier.MatchString(base) {
+		base = "_"
+	}
+	ns := base
+	for i := 2; f.used[ns]; i++ {
+		ns = base + strconv.Itoa(i)
+	}
+	f.imports[m.FullName] = ns
+	f.used[ns] = true
+	return ns
+}
+
+func (f *file) content() []byte {
+	var b bytes.Buffer
+	b.WriteString(Header)
+
+	fullnames := make([]string, 0, len(f.imports))
+	for fullname := range f.imports {
+		fullnames = append(fullnames, fullname)
+	}
+	sort.Slice(fullnames, func(i, j int) bool { return f.imports[fullnames[i]] < f.imports[fullnames[j]] })
+	if len(fullnames) > 0 {
+		b.WriteString("\n")
+	}
+	for _, fullname := range fullnames {
+		fmt.Fprintf(&b, "import type * as %s from %q;\n", f.imports[fullname], relative(f.path, Path(fullname)))
+	}
+
+	names := make([]string, 0, len(f.decls))
+	for name := range f.decls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b.WriteString("\n")
+		b.WriteString(f.decls[name])
+	}
+	return b.Bytes()
+}
+
+// relative returns the module specifier of the file to from the file from (e.g. "../models").
+func relative(from, to string) string {
+	fromDir := strings.Split(path.Dir(from), "/")
+	toParts := strings.Split(strings.TrimSuffix(to, ".d.ts"), "/")
+	i := 0
+	for i < len(fromDir) && i < len(toParts)-1 && fromDir[i] == toParts[i] {
+		i++
+	}
+	var parts []string
+	for range fromDir[i:] {
+		parts = append(parts, "..")
+	}
+	parts = append(parts, toParts[i:]...)
+	if parts[0] != ".." {
+		parts = append([]string{"."}, parts...)
+	}
+	return strings.Join(parts, "/")
+}
+
+// scope is the context of a type expression, in the file.
+type scope struct {
+	*emitter.Scope
+	file *file
+}
+
+func (e *tsEmitter) definition(f *file, def *emitter.Definition) string {
+	var b strings.Builder
+	writeDoc(&b, "", def.Doc())
+
+	s := &scope{Scope: emitter.NewScope(def), file: f}
+	var params []string
+	for _, tp := range def.TypeParams() {
+		params = append(params, tp.Name)
+	}
+	name := def.Ref.Name
+	if len(params) > 0 {
+		name += "<" + strings.Join(params, ", ") + ">"
+	}
+
+	switch {
+	case def.Implements("MarshalJSON"):
+		fmt.Fprintf(&b, "export type %s = unknown;\n", name) // encoded by its own
+	case def.Implements("MarshalText"):
+		fmt.Fprintf(&b, "export type %s = string;\n", name)
+	case def.Struct != nil:
+		e.writeInterface(&b, s, name, def)
+	default:
+		e.writeAlias(&b, s, name, def)
+	}
+	return b.String()
+}
+
+func (e *tsEmitter) writeInterface(b *strings.Builder, s *scope, name string, def *emitter.Definition) {
+	// the embedded structs are expressed with extends, unless they are pointers or some of their fields are hidden (e.g. conflicted with other ones)
+	var extends []string
+	embedded := map[int]bool{}
+	for _, f := range emitter.SortedFields(def.Struct) {
+		if !f.Embed || f.JSON == nil || !f.JSON.Inline {
+			continue
+		}
+		d := emitter.LookupDefinition(e.world.ResolveRef(def.Module, def.Result, f.Type))
+		if d == nil || d.Struct == nil || !emitter.Inherits(def.Struct, f, d.Struct) {
+			continue
+		}
+		extends = append(extends, e.typeExpr(s, f.Type))
+		embedded[f.Index] = true
+	}
+
+	fmt.Fprintf(b, "export interface %s ", name)
+	if len(extends) > 0 {
+		fmt.Fprintf(b, "extends %s ", strings.Join(extends, ", "))
+	}
+	b.WriteString("{\n")
+	for _, wf := range def.Struct.WireFields {
+		if embedded[wf.Index[0]] {
+			continue
+		}
+		fscope, origin := emitter.FieldScope(e.world, def, wf)
+		if origin == nil {
+			continue
+		}
+		if origin != def.Struct {
+			fscope.Params = nil // not declared in the interface, the types of them are unknown
+		}
+		typ := e.typeExpr(&scope{Scope: fscope, file: s.file}, wf.Type)
+		if typ == "" {
+			continue // not encodable
+		}
+		if wf.String {
+			typ = stringOption(typ)
+		}
+		if f, ok := origin.Fields[wf.Field]; ok {
+			writeDoc(b, "  ", emitter.DocOrComment(f.Doc, f.Comment))
+		}
+		optional := ""
+		if wf.OmitEmpty || wf.Indirect {
+			optional = "?"
+		}
+		fmt.Fprintf(b, "  %s%s: %s;\n", propertyName(wf.Name), optional, typ)
+	}
+	b.WriteString("}\n")
+}
+
+func (e *tsEmitter) writeAlias(b *strings.Builder, s *scope, name string, def *emitter.Definition) {
+	candidates := def.Alias.Candidates
+	if len(candidates) == 0 {
+		typ := e.typeExpr(s, def.Alias.Original)
+		if typ == "" {
+			typ = "unknown"
+		}
+		fmt.Fprintf(b, "export type %s = %s;\n", name, typ)
+		return
+	}
+
+	literals := make([]string, len(candidates))
+	enumerable := true // string or number
+	for i, c := range candidates {
+		literals[i] = literal(c)
+		if c.Kind != "string" && c.Kind != "int" && c.Kind != "float" {
+			enumerable = false
+		}
+	}
+	if e.config.ConstEnum && enumerable {
+		fmt.Fprintf(b, "export const enum %s {\n", name)
+		for i, c := range candidates {
+			writeDoc(b, "  ", emitter.DocOrComment(c.Doc, c.Comment))
+			fmt.Fprintf(b, "  %s = %s,\n", c.Name, literals[i])
+		}
+		b.WriteString("}\n")
+		return
+	}
+	fmt.Fprintf(b, "export type %s = %s;\n", name, strings.Join(uniq(literals), " | "))
+}
+
+// typeExpr returns the TypeScript type of typ. returns "" if the values of typ cannot be encoded (e.g. func, chan).
+func (e *tsEmitter) typeExpr(s *scope, typ structjson.Type) string {
+	switch t := typ.(type) {
+	case *structjson.PrimitiveType:
+		if s.IsParam(t.Value) {
+			return t.Value
+		}
+		if ref := e.world.ResolveRef(s.Module, s.Result, typ); ref != nil {
+			return e.refExpr(s, ref, nil)
+		}
+		return builtinType(t.Value)
+	case *structjson.SelectorType:
+		if ref := e.world.ResolveRef(s.Module, s.Result, typ); ref != nil {
+			return e.refExpr(s, ref, nil)
+		}
+		return "unknown"
+	case *structjson.InstantiationType:
+		if ref := e.world.ResolveRef(s.Module, s.Result, typ); ref != nil {
+			return e.refExpr(s, ref, t.Args)
+		}
+		return "unknown"
+	case *structjson.PointerType:
+		value := e.typeExpr(s, t.Value)
+		if value == "" || value == "unknown" || strings.HasSuffix(value, " | null") {
+			return value
+		}
+		return value + " | null"
+	case *structjson.SliceType:
+		if emitter.IsByte(t.Value) {
+			return "string" // base64
+		}
+		return arrayOf(e.typeExpr(s, t.Value))
+	case *structjson.ArrayType:
+		return arrayOf(e.typeExpr(s, t.Value))
+	case *structjson.MapType:
+		value := e.typeExpr(s, t.Value)
+		if value == "" {
+			return ""
+		}
+		key := e.typeExpr(s, t.Key)
+		if key == "" || key == "unknown" || strings.Contains(key, " | ") {
+			key = "string"
+		}
+		return fmt.Sprintf("Record<%s, %s>", key, value)
+	case *structjson.StructType:
+		var props []string
+		for _, f := range t.Fields {
+			if f.JSON == nil || f.JSON.Omitted {
+				continue
+			}
+			typ := e.typeExpr(s, f.Type)
+			if typ == "" {
+				continue
+			}
+			if f.JSON.String {
+				typ = stringOption(typ)
+			}
+			optional := ""
+			if f.JSON.OmitEmpty {
+				optional = "?"
+			}
+			props = append(props, fmt.Sprintf("%s%s: %s", propertyName(f.JSON.Name), optional, typ))
+		}
+		if len(props) == 0 {
+			return "{}"
+		}
+		return "{ " + strings.Join(props, "; ") + " }"
+	case *structjson.FuncType, *structjson.ChanType:
+		return ""
+	default:
+		return "unknown" // interface, etc.
+	}
+}
+
+func (e *tsEmitter) refExpr(s *scope, ref *structjson.Ref, args []structjson.Type) string {
+	if typ, ok := e.config.WellKnown[ref.String()]; ok {
+		return typ
+	}
+	def := emitter.LookupDefinition(ref)
+	if def == nil {
+		return "unknown" // not collected, or interface
+	}
+	if !def.Supported() {
+		return ""
+	}
+	e.queue.Push(def)
+
+	name := ref.Name
+	if def.Module != s.file.module {
+		name = s.file.namespace(def.Module) + "." + name
+	}
+	if len(args) > 0 {
+		exprs := make([]string, len(args))
+		for i, arg := range args {
+			if exprs[i] = e.typeExpr(s, arg); exprs[i] == "" {
+				exprs[i] = "unknown"
+			}
+		}
+		name += "<" + strings.Join(exprs, ", ") + ">"
+	}
+	return name
+}
+
+func builtinType(name string) string {
+	switch name {
+	case "string":
+		return "string"
+	case "bool":
+		return "boolean"
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "uintptr", "byte", "rune", "float32", "float64":
+		return "number"
+	case "complex64", "complex128":
+		return ""
+	default:
+		return "unknown" // any, error
+	}
+}
+
+func arrayOf(typ string) string {
+	if typ == "" {
+		return ""
+	}
+	if strings.Contains(typ, " | ") {
+		return "(" + typ + ")[]"
+	}
+	return typ + "[]"
+}
+
+// stringOption returns the type of the field with ",string" option, the number or boolean value is encoded as a JSON string.
+func stringOption(typ string) string {
+	terms := strings.Split(typ, " | ")
+	for i, term := range terms {
+		if term == "number" || term == "boolean" {
+			terms[i] = "string"
+		}
+	}
+	return strings.Join(terms, " | ")
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+func propertyName(name string) string {
+	if identifier.MatchString(name) {
+		return name
+	}
+	return strconv.Quote(name)
+}
+
+// literal returns the literal type of the candidate (e.g. "female", 1).
+func literal(c *structjson.AliasValue) string {
+	if v, ok := c.Computed.(string); ok && (c.Kind == "int" || c.Kind == "float") {
+		return v // not representable in int64
+	}
+	b, err := json.Marshal(c.Computed)
+	if err != nil {
+		return "unknown"
+	}
+	return string(b)
+}
+
+func uniq(xs []string) []string {
+	seen := map[string]bool{}
+	var r []string
+	for _, x := range xs {
+		if !seen[x] {
+			seen[x] = true
+			r = append(r, x)
+		}
+	}
+	return r
+}
+
+func writeDoc(b *strings.Builder, indent string, doc string) {
+	doc = strings.TrimSpace(doc)
+	if doc == "" {
+		return
+	}
+	lines := strings.Split(doc, "\n")
+	if len(lines) == 1 {
+		fmt.Fprintf(b, "%s/** %s */\n", indent, strings.ReplaceAll(doc, "*/", "* /"))
+		return
+	}
+	fmt.Fprintf(b, "%s/**\n", indent)
+	for _, line := range lines {
+		fmt.Fprintf(b, "%s * %s\n", indent, strings.ReplaceAll(line, "*/", "* /"))
+	}
+	fmt.Fprintf(b, "%s */\n", indent)
+}
@@ -0,0 +1,73 @@
+package typescript
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/podhmo/go-structjson/emitter/internal/golden"
+)
+
+func TestEmitGolden(t *testing.T) {
+	files, err := Emit(golden.Load(t, "jsonfields", "rpc"), NewConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden.CompareFiles(t, "emit-typescript", "examples/output/typescript", files)
+}
+
+func TestEnum(t *testing.T) {
+	w := golden.Load(t, "enum")
+	cases := []struct {
+		msg       string
+		constEnum bool
+		want      []string
+	}{
+		{
+			msg:  "union",
+			want: []string{"export type Status = 1 | 2 | 3;\n", "export type Color = \"color-red\" | \"color-green\";\n"},
+		},
+		{
+			msg:       "const enum",
+			constEnum: true,
+			want: []string{
+				"export const enum Status {\n  StatusActive = 1,\n  StatusInactive = 2,\n  StatusDeleted = 3,\n}\n",
+				"export const enum Level {\n  /** LevelDebug : verbose */\n  LevelDebug = \"debug\",\n  /** default */\n  LevelInfo = \"info\",\n}\n",
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.msg, func(t *testing.T) {
+			config := NewConfig()
+			config.ConstEnum = c.constEnum
+			files, err := Emit(w, config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != 1 {
+				t.Fatalf("want 1 file, but %d", len(files))
+			}
+			for _, want := range c.want {
+				if !strings.Contains(string(files[0].Content), want) {
+					t.Errorf("want\n%s\nin\n%s", want, files[0].Content)
+				}
+			}
+		})
+	}
+}
+
+func TestRelative(t *testing.T) {
+	cases := []struct {
+		from, to string
+		want     string
+	}{
+		{from: "example.com/x/models.d.ts", to: "example.com/x/shared.d.ts", want: "./shared"},
+		{from: "example.com/x/models.d.ts", to: "example.com/x/models/sub.d.ts", want: "./models/sub"},
+		{from: "example.com/x/models/sub.d.ts", to: "example.com/x/shared.d.ts", want: "../shared"},
+		{from: "example.com/x/models.d.ts", to: "time.d.ts", want: "../../time"},
+	}
+	for _, c := range cases {
+		if got := relative(c.from, c.to); got != c.want {
+			t.Errorf("relative(%q, %q): want %q, but %q", c.from, c.to, c.want, got)
+		}
+	}
+}
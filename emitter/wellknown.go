@@ -0,0 +1,43 @@
+package emitter
+
+import structjson "github.com/podhmo/go-structjson"
+
+// WellKnownType is the JSON value of a well-known type, which is not emitted as a definition.
+type WellKnownType struct {
+	Type   string // "string", "integer" or "number". "" if any JSON value
+	Format string // format of the string (e.g. "date-time", "uuid")
+}
+
+// WellKnown returns the JSON values of the well-known types, keyed by the qualified name with the full package name (e.g. "time.Time").
+// each emitter maps them to its own types.
+func WellKnown() map[string]WellKnownType {
+	str := func(format string) WellKnownType {
+		return WellKnownType{Type: "string", Format: format}
+	}
+	return map[string]WellKnownType{
+		"time.Time":                             str("date-time"),
+		"time.Duration":                         {Type: "integer"}, // nanoseconds
+		"encoding/json.RawMessage":              {},
+		"encoding/json.Number":                  {Type: "number"},
+		"net/url.URL":                           str("uri"),
+		"net.IP":                                str(""),
+		"math/big.Int":                          {Type: "integer"},
+		"gopkg.in/mgo.v2/bson.ObjectId":         str(""),
+		"github.com/go-openapi/strfmt.DateTime": str("date-time"),
+		"github.com/go-openapi/strfmt.Date":     str("date"),
+		"github.com/go-openapi/strfmt.Duration": str("duration"),
+		"github.com/go-openapi/strfmt.Email":    str("email"),
+		"github.com/go-openapi/strfmt.Hostname": str("hostname"),
+		"github.com/go-openapi/strfmt.IPv4":     str("ipv4"),
+		"github.com/go-openapi/strfmt.IPv6":     str("ipv6"),
+		"github.com/go-openapi/strfmt.URI":      str("uri"),
+		"github.com/go-openapi/strfmt.UUID":     str("uuid"),
+		"github.com/go-openapi/strfmt.Password": str("password"),
+	}
+}
+
+// IsByte reports whether typ is byte (or uint8). []byte is encoded as a base64 string by encoding/json.
+func IsByte(typ structjson.Type) bool {
+	t, ok := typ.(*structjson.PrimitiveType)
+	return ok && (t.Value == "byte" || t.Value == "uint8") && (t.TypeInfo == nil || t.TypeInfo.Builtin)
+}
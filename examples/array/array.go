@@ -6,3 +6,22 @@ type Keyword struct {
 }
 
 type Keywords []Keyword
+
+// Size is the size of ID.
+const Size = 4 * 4
+
+// UUID is a fixed-size array.
+type UUID [16]byte
+
+// ID is a fixed-size array, with the length of a constant expression.
+type ID [Size]byte
+
+// Matrix :
+type Matrix [2][Size / 8]float64
+
+// Entry :
+type Entry struct {
+	UUID   UUID           `json:"uuid"`
+	Digest [Size * 2]byte `json:"digest"`
+	Tags   []string       `json:"tags"`
+}
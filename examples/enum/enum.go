@@ -0,0 +1,50 @@
+package enum
+
+// Status :
+type Status int
+
+// Status : constants
+const (
+	StatusActive Status = iota + 1
+	StatusInactive
+	StatusDeleted
+)
+
+// Permission :
+type Permission uint8
+
+// Permission : flags
+const (
+	PermissionRead Permission = 1 << iota
+	PermissionWrite
+	PermissionExec
+
+	PermissionAll = PermissionRead | PermissionWrite | PermissionExec
+)
+
+// Color :
+type Color string
+
+const prefix = "color-"
+
+// Color : constants
+const (
+	ColorRed   Color = prefix + "red"
+	ColorGreen Color = prefix + "green"
+)
+
+// Ratio :
+type Ratio float64
+
+// Half :
+const Half Ratio = 1.0 / 2
+
+// Level : log level
+const (
+	// LevelDebug : verbose
+	LevelDebug Level = "debug"
+	LevelInfo  Level = "info" // default
+)
+
+// Level :
+type Level string // log level
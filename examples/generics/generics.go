@@ -0,0 +1,37 @@
+package generics
+
+// Number :
+type Number interface {
+	~int | ~int64 | ~float64
+}
+
+// Page : paginated items
+type Page[T any] struct {
+	Items []T `json:"items"`
+	Next  *T  `json:"next"`
+}
+
+// Pair :
+type Pair[K comparable, V any] struct {
+	Key   K `json:"key"`
+	Value V `json:"value"`
+}
+
+// Total :
+type Total[N Number] struct {
+	Sum N `json:"sum"`
+}
+
+// List :
+type List[T any] []T
+
+// People :
+type People struct {
+	Page[Person]
+	Pairs List[Pair[string, int]] `json:"pairs"`
+}
+
+// Person :
+type Person struct {
+	Name string `json:"name"`
+}
@@ -2,3 +2,21 @@ package i
 
 type I interface {
 }
+
+// Closer :
+type Closer interface {
+	Close() error
+}
+
+// Store :
+type Store interface {
+	Closer
+	Get(key string) (value []byte, err error)
+	Put(key string, value []byte) error
+	Keys(prefix ...string) []string
+}
+
+// Ordered :
+type Ordered interface {
+	~int | ~int64 | ~string
+}
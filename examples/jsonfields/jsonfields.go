@@ -0,0 +1,44 @@
+package jsonfields
+
+// Base :
+type Base struct {
+	ID        string `json:"id"`
+	CreatedAt string `json:"createdAt,omitempty"`
+	Note      string `json:"note"`
+}
+
+// Audit :
+type Audit struct {
+	CreatedAt string `json:"createdAt"`
+	UpdatedAt string `json:"updatedAt"`
+	Note      string `json:"note"`
+}
+
+// Group :
+type Group struct {
+	Name string `json:"name"`
+}
+
+// Admin :
+type Admin struct {
+	*Base
+	Audit
+	Group    `json:"group"`
+	Level    int    `json:"level,string"`
+	Password string `json:"-"`
+	Dash     string `json:"-,"`
+	Name     string
+	NAME     string `json:"name"`
+	secret   string
+}
+
+type base struct {
+	ID   string `json:"id"`
+	Kind string `json:"kind"`
+}
+
+// Item :
+type Item struct {
+	base
+	Name string `json:"name"`
+}
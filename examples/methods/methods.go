@@ -0,0 +1,73 @@
+package methods
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Status is a defined type with methods.
+type Status int
+
+// String implements fmt.Stringer.
+func (s Status) String() string {
+	return fmt.Sprintf("status(%d)", int(s))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s Status) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Name :
+type Name struct {
+	First string `json:"first"`
+	Last  string `json:"last"`
+}
+
+// String returns the full name.
+func (n Name) String() string {
+	return n.First + " " + n.Last
+}
+
+// SetFull sets the name from a full name.
+func (n *Name) SetFull(full string) {
+	parts := strings.SplitN(full, " ", 2)
+	n.First, n.Last = parts[0], parts[len(parts)-1]
+}
+
+// Closer :
+type Closer interface {
+	Close() error
+}
+
+// Resource :
+type Resource interface {
+	Closer
+	Open(name string, flags ...int) error
+}
+
+// Timestamps :
+type Timestamps struct {
+	CreatedAt string `json:"createdAt"`
+}
+
+// Touch :
+func (t *Timestamps) Touch() {}
+
+// String :
+func (t Timestamps) String() string { return t.CreatedAt }
+
+// User has methods promoted from embedded fields.
+type User struct {
+	Name        // Name.String and (*Name).SetFull are promoted
+	*Timestamps // Timestamps.String is ambiguous with Name.String
+	Resource
+	Status Status `json:"status"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (u *User) MarshalJSON() ([]byte, error) {
+	type plain User
+	return json.Marshal((*plain)(u))
+}
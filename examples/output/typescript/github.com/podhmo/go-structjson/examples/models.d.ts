@@ -0,0 +1,13 @@
+// Code generated by go-structjson. DO NOT EDIT.
+
+/** Person : person model */
+export interface Person {
+  id: string;
+  name: string;
+  age: number;
+  gender: PersonGender;
+  groupId?: string | null;
+}
+
+/** PersonGender : gender */
+export type PersonGender = "female" | "male" | "unknown";
@@ -0,0 +1,52 @@
+package rpc
+
+import (
+	"time"
+
+	"github.com/podhmo/go-structjson/examples/models"
+)
+
+// OrderStatus :
+type OrderStatus int
+
+// OrderStatus : constants
+const (
+	OrderStatusUnknown OrderStatus = iota
+	OrderStatusPending
+	OrderStatusShipped
+)
+
+// Item :
+type Item struct {
+	Name     string  `json:"name"`
+	Quantity int32   `json:"quantity"`
+	Price    float64 `json:"price"`
+}
+
+// Order : the field numbers of ID and CreatedAt are pinned
+type Order struct {
+	ID        string            `json:"id" protobuf:"bytes,1,opt,name=id"`
+	Customer  *models.Person    `json:"customer"`
+	Items     []Item            `json:"items"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Status    OrderStatus       `json:"status"`
+	Note      *string           `json:"note,omitempty" graphql:"memo"`
+	CreatedAt time.Time         `json:"createdAt" protobuf:"10"`
+	UpdatedAt *time.Time        `json:"updatedAt,omitempty"`
+	Token     string            `json:"token" graphql:"-"`
+}
+
+// Priced :
+type Priced interface {
+	// Total : the total price
+	Total() float64
+}
+
+// Total implements Priced.
+func (o *Order) Total() float64 {
+	var total float64
+	for _, item := range o.Items {
+		total += float64(item.Quantity) * item.Price
+	}
+	return total
+}
@@ -0,0 +1,25 @@
+package shapes
+
+import "context"
+
+// Logger is a variadic func type.
+type Logger func(ctx context.Context, format string, args ...interface{})
+
+// Source is a receive-only channel.
+type Source <-chan string
+
+// Sink is a send-only channel.
+type Sink chan<- string
+
+// Response has anonymous struct fields.
+type Response struct {
+	Meta struct {
+		Total int    `json:"total"`
+		Next  string `json:"next,omitempty"`
+	} `json:"meta"`
+	Items []struct {
+		ID   string `json:"id"`
+		Tags []string
+	} `json:"items"`
+	OnDone func(err error) `json:"-"`
+}
@@ -0,0 +1,51 @@
+package typealias
+
+import (
+	"github.com/go-openapi/strfmt"
+	"github.com/podhmo/go-structjson/examples/jsonfields"
+)
+
+// Email is a new defined type, it has not the methods of strfmt.Email.
+type Email strfmt.Email
+
+// EmailAlias is a true alias, same as strfmt.Email.
+type EmailAlias = strfmt.Email
+
+// Group is an alias of the struct defined in other package.
+type Group = jsonfields.Group
+
+// Team is an alias of alias.
+type Team = Group
+
+// Point :
+type Point = struct {
+	X, Y int
+}
+
+// Handler :
+type Handler = func(name string) error
+
+// Member :
+type Member struct {
+	Team
+	Email Email      `json:"email"`
+	Alias EmailAlias `json:"alias"`
+	Point Point      `json:"point"`
+}
+
+// Event :
+type Event struct {
+	Name string `json:"name"`
+}
+
+// Events is a named chan type.
+type Events chan Event
+
+// Callback is a named func type.
+type Callback func(ev Event) error
+
+// Failure is a named type of builtin interface.
+type Failure error
+
+// Leader is a named type of struct.
+type Leader Member
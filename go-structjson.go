@@ -5,61 +5,79 @@ import (
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"go/types"
 	"os"
 	"path"
 	"sort"
 	"strconv"
-	"strings"
-
-	"github.com/davecgh/go-spew/spew"
 )
 
 type World struct {
-	Modules map[string]*Module `json:"module"`
+	Modules     map[string]*Module `json:"module"` // keyed by the full name of the package
+	Roots       []string           `json:"-"`      // full names of the modules matching the patterns of Load
+	Diagnostics Diagnostics        `json:"-"`
 }
 type Module struct {
 	Name     string             `json:"name"`
 	FullName string             `json:"fullname"`
 	Files    map[string]*Result `json:"file"`
+
+	hiddenFiles map[string]*Result // files having only the types excluded by the type visibility
 }
 
 func NewWorld() *World {
 	return &World{Modules: make(map[string]*Module)}
 }
 func NewModule(name string) *Module {
-	return &Module{Name: name, Files: make(map[string]*Result)}
+	return &Module{Name: name, Files: make(map[string]*Result), hiddenFiles: make(map[string]*Result)}
 }
 
+// indexAliasValues sorts the values in declaration order (by the file, and the index in the file).
 type indexAliasValues []*AliasValue
 
-func (p indexAliasValues) Len() int           { return len(p) }
-func (p indexAliasValues) Less(i, j int) bool { return p[i].i < p[j].i }
-func (p indexAliasValues) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
-
-type nameAliasValues []*AliasValue
-
-func (p nameAliasValues) Len() int           { return len(p) }
-func (p nameAliasValues) Less(i, j int) bool { return p[i].Name < p[j].Name }
-func (p nameAliasValues) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+func (p indexAliasValues) Len() int { return len(p) }
+func (p indexAliasValues) Less(i, j int) bool {
+	if p[i].file != p[j].file {
+		return p[i].file < p[j].file
+	}
+	return p[i].Index < p[j].Index
+}
+func (p indexAliasValues) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
 
 type Result struct {
-	Name          string                          `json:"name"`
-	AliasMap      map[string]*AliasDefinition     `json:"alias,omitempty"`
-	StructMap     map[string]*StructDefinition    `json:"struct,omitempty"`
-	InterfaceMap  map[string]*InterfaceDefinition `json:"interface,omitempty"`
-	MaybeAliasses []*AliasValue                   `json:"-"`
-	ImportsMap    map[string]*ImportDefinition    `json:"import,omitempty"`
-	i             int
+	Name            string                          `json:"name"`
+	AliasMap        map[string]*AliasDefinition     `json:"alias,omitempty"`
+	StructMap       map[string]*StructDefinition    `json:"struct,omitempty"`
+	InterfaceMap    map[string]*InterfaceDefinition `json:"interface,omitempty"`
+	FuncMap         map[string]*FuncDefinition      `json:"function,omitempty"` // nil if functions are not collected
+	MaybeAliasses   []*AliasValue                   `json:"-"`
+	Methods         []*MethodDefinition             `json:"-"` // attached to the receiver's definition, see World.ComputeMethodSets
+	ImportsMap      map[string]*ImportDefinition    `json:"import,omitempty"`
+	Diagnostics     Diagnostics                     `json:"-"`
+	i               int                             // counter of declaration order
+	info            *types.Info                     // nil if not type-checked
+	consts          *constEvaluator
+	genDecls        map[ast.Spec]*ast.GenDecl    // parent of each spec
+	fset            *token.FileSet               // nil if positions are not available
+	root            string                       // if not empty, filenames of positions are relative to this
+	typeVisibility  Visibility                   // of types and functions
+	fieldVisibility Visibility                   // of struct fields
+	hiddenStructMap map[string]*StructDefinition // structs excluded by typeVisibility, to resolve embedded fields
+	hiddenTypes     map[string]bool              // names of all types excluded by typeVisibility
 }
 
 func NewResult(name string) *Result {
 	return &Result{
-		Name:          name,
-		StructMap:     make(map[string]*StructDefinition),
-		InterfaceMap:  make(map[string]*InterfaceDefinition),
-		AliasMap:      make(map[string]*AliasDefinition),
-		MaybeAliasses: []*AliasValue{},
-		ImportsMap:    make(map[string]*ImportDefinition),
+		Name:            name,
+		StructMap:       make(map[string]*StructDefinition),
+		InterfaceMap:    make(map[string]*InterfaceDefinition),
+		AliasMap:        make(map[string]*AliasDefinition),
+		MaybeAliasses:   []*AliasValue{},
+		ImportsMap:      make(map[string]*ImportDefinition),
+		typeVisibility:  VisibilityExported,
+		fieldVisibility: VisibilityAll,
+		hiddenStructMap: make(map[string]*StructDefinition),
+		hiddenTypes:     make(map[string]bool),
 	}
 }
 
@@ -68,26 +86,95 @@ func (r *Result) AddStruct(ob *ast.Object) (*StructDefinition, error) {
 	if !exists {
 		item = &StructDefinition{}
 	}
+	item.Index = r.i
+	r.i++
+	r.StructMap[ob.Name] = item
+	return item, r.fillStruct(item, ob)
+}
+
+// addHiddenStruct collects the struct excluded by the type visibility.
+// it is not emitted, but the fields promoted through it are needed if it is embedded.
+func (r *Result) addHiddenStruct(ob *ast.Object) (*StructDefinition, error) {
+	item := &StructDefinition{}
+	r.hiddenStructMap[ob.Name] = item
+	return item, r.fillStruct(item, ob)
+}
+
+func (r *Result) fillStruct(item *StructDefinition, ob *ast.Object) error {
 	item.rawDef = ob
 	item.Name = ob.Name
-	r.StructMap[ob.Name] = item
-	fields, err := findFields(r, ob.Decl.(ast.Node))
+	item.Exported = token.IsExported(ob.Name)
+	item.Alias = ob.Decl.(*ast.TypeSpec).Assign.IsValid()
+	item.TypeParams = findTypeParams(r, ob.Decl.(*ast.TypeSpec).TypeParams)
+	item.Doc = r.findDoc(ob.Decl.(*ast.TypeSpec), ob.Decl.(*ast.TypeSpec).Doc)
+	item.Comment = commentText(ob.Decl.(*ast.TypeSpec).Comment)
+	item.Pos = r.findPos(ob.Decl.(*ast.TypeSpec).Pos(), ob.Decl.(*ast.TypeSpec).End())
+	fields, hidden, err := findFields(r, ob.Decl.(ast.Node))
 	item.Fields = fields
-	return item, err
+	item.hiddenEmbeds = hidden
+	return err
 }
 
 func (r *Result) AddInterface(ob *ast.Object) (*InterfaceDefinition, error) {
-	// FIXME: not support seriously
 	item, exists := r.InterfaceMap[ob.Name]
 	if !exists {
 		item = &InterfaceDefinition{}
 	}
 	item.rawDef = ob
 	item.Name = ob.Name
+	item.Exported = token.IsExported(ob.Name)
+	item.Index = r.i
+	r.i++
+	item.Alias = ob.Decl.(*ast.TypeSpec).Assign.IsValid()
+	item.TypeParams = findTypeParams(r, ob.Decl.(*ast.TypeSpec).TypeParams)
+	item.Doc = r.findDoc(ob.Decl.(*ast.TypeSpec), ob.Decl.(*ast.TypeSpec).Doc)
+	item.Comment = commentText(ob.Decl.(*ast.TypeSpec).Comment)
+	item.Pos = r.findPos(ob.Decl.(*ast.TypeSpec).Pos(), ob.Decl.(*ast.TypeSpec).End())
+	item.Methods = []*Method{}
+	item.Embeds = nil
+	item.TypeSet = nil
+	for _, field := range ob.Decl.(*ast.TypeSpec).Type.(*ast.InterfaceType).Methods.List {
+		if len(field.Names) > 0 {
+			ftype := field.Type.(*ast.FuncType)
+			for _, name := range field.Names {
+				item.Methods = append(item.Methods, &Method{
+					Name:     name.Name,
+					Exported: token.IsExported(name.Name),
+					Params:   findParams(r, ftype.Params),
+					Results:  findParams(r, ftype.Results),
+					Variadic: isVariadic(ftype),
+					Doc:      commentText(field.Doc),
+					Comment:  commentText(field.Comment),
+					Pos:      r.findPos(name.Pos(), field.End()),
+				})
+			}
+			continue
+		}
+		if r.isEmbeddedInterface(field.Type) {
+			item.Embeds = append(item.Embeds, FindType(r, field.Type))
+		} else {
+			item.TypeSet = append(item.TypeSet, FindType(r, field.Type))
+		}
+	}
 	r.InterfaceMap[ob.Name] = item
 	return item, nil
 }
 
+// isEmbeddedInterface reports whether expr, an element of interface, is an embedded interface (not a type-set term).
+func (r *Result) isEmbeddedInterface(expr ast.Expr) bool {
+	switch expr.(type) {
+	case *ast.Ident, *ast.SelectorExpr, *ast.IndexExpr, *ast.IndexListExpr:
+		if r.info != nil {
+			if typ := r.info.TypeOf(expr); typ != nil {
+				return types.IsInterface(typ)
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
 type nameVisitor struct{ Found string }
 
 func (v *nameVisitor) Visit(node ast.Node) ast.Visitor {
@@ -99,157 +186,264 @@ func (v *nameVisitor) Visit(node ast.Node) ast.Visitor {
 }
 
 func findName(node ast.Node) string {
+	switch node := node.(type) {
+	case *ast.StarExpr:
+		return findName(node.X)
+	case *ast.IndexExpr:
+		return findName(node.X) // e.g. Page[T]
+	case *ast.IndexListExpr:
+		return findName(node.X)
+	}
 	namevisitor := &nameVisitor{Found: ""}
 	ast.Walk(namevisitor, node)
 	return namevisitor.Found
 }
 
 type Field struct {
-	Name  string              `json:"name"`
-	Tags  map[string][]string `json:"tags"`
-	Type  Type                `json:"type"`
-	Embed bool                `json:"embed"`
+	Name      string              `json:"name"`
+	Exported  bool                `json:"exported"`
+	Index     int                 `json:"index"`               // declaration order in struct
+	Tag       string              `json:"tag,omitempty"`       // raw struct tag
+	TagValues map[string]string   `json:"tagvalues,omitempty"` // raw value per key
+	Tags      map[string][]string `json:"tags"`                // comma-split options per key
+	Type      Type                `json:"type"`
+	Embed     bool                `json:"embed"`
+	JSON      *JSONField          `json:"json"`
+	Doc       string              `json:"doc,omitempty"`
+	Comment   string              `json:"comment,omitempty"` // line comment
+	Pos       *Pos                `json:"pos,omitempty"`
 }
 
-type Type interface{}
 type fieldsVisitor struct {
+	Name   string // name of struct
 	Found  map[string]*Field
+	Hidden []*Field // unexported embedded fields excluded by the field visibility
 	Result *Result
+	n      int // number of visited fields, including hidden ones
 }
 
 func (v *fieldsVisitor) Visit(node ast.Node) ast.Visitor {
-	structNode := node.(*ast.TypeSpec).Type.(*ast.StructType)
+	v.Name = node.(*ast.TypeSpec).Name.Name
+	v.visitStruct(node.(*ast.TypeSpec).Type.(*ast.StructType))
+	return nil
+}
+
+func (v *fieldsVisitor) visitStruct(structNode *ast.StructType) {
 	if structNode.Incomplete {
-		panic(fmt.Sprintf("%s is incomplete struct definition", node.(*ast.TypeSpec).Name.Name))
+		v.Result.addDiagnostic(SeverityError, structNode, fmt.Sprintf("%s is incomplete struct definition", v.Name))
 	}
 	for _, field := range structNode.Fields.List {
 		if err := v.visitField(field); err != nil {
-			panic(err)
-		}
-	}
-	return nil
-}
-
-func parseTags(node *ast.Field) map[string][]string {
-	tags := map[string][]string{}
-	if node.Tag != nil {
-		unquoted, err := strconv.Unquote(node.Tag.Value)
-		if err != nil {
-			panic(err)
-		}
-		for _, section := range strings.Split(unquoted, " ") {
-			nameAndargs := strings.SplitN(section, ":", 2)
-			if len(nameAndargs) == 2 {
-				unquotedArgs, err := strconv.Unquote(nameAndargs[1])
-				if err != nil {
-					fmt.Fprintf(os.Stderr, "scan tags failure %q\n", unquoted)
-				}
-				for _, arg := range strings.Split(unquotedArgs, ",") {
-					trimmed := strings.Trim(arg, " ")
-					tags[nameAndargs[0]] = append(tags[nameAndargs[0]], trimmed)
-				}
-			}
+			v.Result.addDiagnostic(SeverityWarning, field, err.Error())
 		}
 	}
-	return tags
 }
 
 func (v *fieldsVisitor) visitField(node *ast.Field) error {
 	typ := FindType(v.Result, node.Type)
+	rawTag := ""
+	if node.Tag != nil {
+		rawTag = node.Tag.Value
+	}
 	if len(node.Names) == 0 {
 		name := findName(node.Type)
-		v.Found[name] = &Field{
-			Name:  name,
-			Embed: true,
-			Tags:  parseTags(node),
-			Type:  typ,
+		tag, tagValues, tags := v.Result.parseTags(node, v.Name+"."+name, rawTag)
+		field := &Field{
+			Name:      name,
+			Exported:  token.IsExported(name),
+			Index:     v.n,
+			Embed:     true,
+			Doc:       commentText(node.Doc),
+			Comment:   commentText(node.Comment),
+			Pos:       v.Result.findPos(node.Pos(), node.End()),
+			Tag:       tag,
+			TagValues: tagValues,
+			Tags:      tags,
+			Type:      typ,
+		}
+		field.JSON = newJSONField(field)
+		v.n++
+		if !v.Result.fieldVisibility.includes(name) {
+			// not emitted, but the fields are promoted through it.
+			v.Hidden = append(v.Hidden, field)
+			return nil
 		}
+		v.Found[name] = field
 		return nil
 	}
 	for _, nameNode := range node.Names {
-		tags := []string{}
-		if node.Tag != nil {
-			tags = append(tags, node.Tag.Value)
-		}
 		embed := false
 		name := nameNode.Name
-		v.Found[name] = &Field{
-			Name:  name,
-			Embed: embed,
-			Tags:  parseTags(node),
-			Type:  typ,
+		tag, tagValues, tags := v.Result.parseTags(node, v.Name+"."+name, rawTag)
+		field := &Field{
+			Name:      name,
+			Exported:  token.IsExported(name),
+			Index:     v.n,
+			Embed:     embed,
+			Doc:       commentText(node.Doc),
+			Comment:   commentText(node.Comment),
+			Pos:       v.Result.findPos(nameNode.Pos(), node.End()),
+			Tag:       tag,
+			TagValues: tagValues,
+			Tags:      tags,
+			Type:      typ,
 		}
-
+		field.JSON = newJSONField(field)
+		v.n++
+		if !v.Result.fieldVisibility.includes(name) {
+			continue
+		}
+		v.Found[name] = field
 	}
 	return nil
 }
 
 func FindType(r *Result, node ast.Node) Type {
-	m := make(map[string]Type)
 	switch node := node.(type) {
 	case *ast.Ident:
-		m["kind"] = "primitive"
-		m["value"] = node.Name
+		return &PrimitiveType{Value: node.Name, TypeInfo: r.findTypeInfo(node)}
 	case *ast.ArrayType:
-		m["kind"] = "array"
-		m["value"] = FindType(r, node.Elt)
+		if node.Len == nil {
+			return &SliceType{Value: FindType(r, node.Elt)}
+		}
+		t := &ArrayType{Value: FindType(r, node.Elt), Len: r.arrayLen(node)}
+		if _, ok := node.Len.(*ast.BasicLit); !ok {
+			t.LenExpr = types.ExprString(node.Len)
+		}
+		return t
 	case *ast.MapType:
-		m["kind"] = "map"
-		m["key"] = FindType(r, node.Key)
-		m["value"] = FindType(r, node.Value)
+		return &MapType{Key: FindType(r, node.Key), Value: FindType(r, node.Value)}
 	case *ast.StructType:
-		m["kind"] = "struct"
-		m["fields"] = FindType(r, node.Fields)
+		return &StructType{Fields: findStructFields(r, node)}
 	case *ast.InterfaceType:
-		m["kind"] = "interface"
-		m["methods"] = FindType(r, node.Methods)
+		return &InterfaceType{Methods: findTypes(r, node.Methods)}
 	case *ast.StarExpr:
-		m["kind"] = "pointer"
-		m["value"] = FindType(r, node.X)
+		return &PointerType{Value: FindType(r, node.X)}
 	case *ast.SelectorExpr:
-		m["kind"] = "selector"
-		m["prefix"] = node.X.(*ast.Ident).Name
-		m["value"] = node.Sel.Name
-		if def, exists := r.ImportsMap[node.X.(*ast.Ident).Name]; exists {
+		prefix, ok := node.X.(*ast.Ident)
+		if !ok {
+			break
+		}
+		if def, exists := r.ImportsMap[prefix.Name]; exists {
 			def.NeedParse = true
 		}
+		return &SelectorType{Prefix: prefix.Name, Value: node.Sel.Name, TypeInfo: r.findTypeInfo(node)}
 	case *ast.FuncType:
-		m["kind"] = "func"
-		m["args"] = FindType(r, node.Params)
-		m["results"] = FindType(r, node.Results)
+		return &FuncType{Params: findParams(r, node.Params), Results: findParams(r, node.Results), Variadic: isVariadic(node)}
 	case *ast.TypeSpec:
 		return FindType(r, node.Type)
 	case *ast.ChanType:
-		m["kind"] = "channel"
-		m["value"] = FindType(r, node.Value)
-		m["dir"] = node.Dir
-		// xxx
-	case *ast.FieldList:
-		if node == nil {
-			return []Type{}
+		return &ChanType{Value: FindType(r, node.Value), Dir: chanDir(node.Dir)}
+	case *ast.Ellipsis:
+		return &EllipsisType{Value: FindType(r, node.Elt)}
+	case *ast.IndexExpr:
+		return &InstantiationType{Value: FindType(r, node.X), Args: []Type{FindType(r, node.Index)}}
+	case *ast.IndexListExpr:
+		args := make([]Type, len(node.Indices))
+		for i, index := range node.Indices {
+			args[i] = FindType(r, index)
 		}
-		args := make([]Type, len(node.List))
-		for i, arg := range node.List {
-			args[i] = FindType(r, arg.Type)
+		return &InstantiationType{Value: FindType(r, node.X), Args: args}
+	case *ast.ParenExpr:
+		return FindType(r, node.X)
+	case *ast.BinaryExpr:
+		// type set of constraint (e.g. ~int | ~string)
+		if node.Op == token.OR {
+			var terms []Type
+			for _, x := range []ast.Expr{node.X, node.Y} {
+				t := FindType(r, x)
+				if union, ok := t.(*UnionType); ok {
+					terms = append(terms, union.Terms...)
+				} else {
+					terms = append(terms, t)
+				}
+			}
+			return &UnionType{Terms: terms}
+		}
+	case *ast.UnaryExpr:
+		if node.Op == token.TILDE {
+			return &ApproximationType{Value: FindType(r, node.X)}
 		}
-		return args
-	case *ast.Ellipsis:
-		m["kind"] = "ellipsis"
-		m["value"] = FindType(r, node.Elt)
-	default:
-		spew.Dump(node)
-		panic(node)
 	}
-	return m
+	r.addDiagnostic(SeverityWarning, node, fmt.Sprintf("unsupported type expression %T", node))
+	value := ""
+	if expr, ok := node.(ast.Expr); ok {
+		value = types.ExprString(expr)
+	}
+	return &UnknownType{Value: value}
 }
 
-func findFields(r *Result, val ast.Node) (map[string]*Field, error) {
+func findTypeParams(r *Result, node *ast.FieldList) []*TypeParam {
+	if node == nil {
+		return nil
+	}
+	var params []*TypeParam
+	for _, field := range node.List {
+		constraint := FindType(r, field.Type)
+		for _, name := range field.Names {
+			params = append(params, &TypeParam{Name: name.Name, Constraint: constraint, Pos: r.findPos(name.Pos(), field.End())})
+		}
+	}
+	return params
+}
+
+func findParams(r *Result, node *ast.FieldList) []*Param {
+	params := []*Param{}
+	if node == nil {
+		return params
+	}
+	for _, field := range node.List {
+		typ := FindType(r, field.Type)
+		if len(field.Names) == 0 {
+			params = append(params, &Param{Type: typ})
+			continue
+		}
+		for _, name := range field.Names {
+			params = append(params, &Param{Name: name.Name, Type: typ})
+		}
+	}
+	return params
+}
+
+// isVariadic reports whether the last parameter of node is variadic (e.g. ...string).
+func isVariadic(node *ast.FuncType) bool {
+	if node.Params == nil || len(node.Params.List) == 0 {
+		return false
+	}
+	_, ok := node.Params.List[len(node.Params.List)-1].Type.(*ast.Ellipsis)
+	return ok
+}
+
+func findTypes(r *Result, node *ast.FieldList) []Type {
+	if node == nil {
+		return []Type{}
+	}
+	args := make([]Type, len(node.List))
+	for i, arg := range node.List {
+		args[i] = FindType(r, arg.Type)
+	}
+	return args
+}
+
+// findStructFields returns the fields of the anonymous struct node, in declaration order.
+func findStructFields(r *Result, node *ast.StructType) []*Field {
+	v := &fieldsVisitor{Name: "struct", Result: r, Found: make(map[string]*Field)}
+	v.visitStruct(node)
+	fields := make([]*Field, 0, len(v.Found))
+	for _, f := range v.Found {
+		fields = append(fields, f)
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Index < fields[j].Index })
+	return fields
+}
+
+func findFields(r *Result, val ast.Node) (map[string]*Field, []*Field, error) {
 	v := &fieldsVisitor{Result: r, Found: make(map[string]*Field)}
 	ast.Walk(v, val)
 	if v.Found == nil {
-		return nil, fmt.Errorf("fields is not found")
+		return nil, nil, fmt.Errorf("fields is not found")
 	}
-	return v.Found, nil
+	return v.Found, v.Hidden, nil
 }
 
 func (r *Result) AddAlias(ob *ast.Object) (*AliasDefinition, error) {
@@ -259,8 +453,17 @@ func (r *Result) AddAlias(ob *ast.Object) (*AliasDefinition, error) {
 	}
 	item.rawDef = ob
 	item.Name = ob.Name
+	item.Exported = token.IsExported(ob.Name)
+	item.Index = r.i
+	r.i++
 	if ob.Decl != nil {
+		item.Alias = ob.Decl.(*ast.TypeSpec).Assign.IsValid()
 		item.Original = FindType(r, ob.Decl.(*ast.TypeSpec))
+		item.Kind = r.findUnderlyingKind(ob.Decl.(*ast.TypeSpec))
+		item.TypeParams = findTypeParams(r, ob.Decl.(*ast.TypeSpec).TypeParams)
+		item.Doc = r.findDoc(ob.Decl.(*ast.TypeSpec), ob.Decl.(*ast.TypeSpec).Doc)
+		item.Comment = commentText(ob.Decl.(*ast.TypeSpec).Comment)
+		item.Pos = r.findPos(ob.Decl.(*ast.TypeSpec).Pos(), ob.Decl.(*ast.TypeSpec).End())
 	}
 	r.AliasMap[ob.Name] = item
 	if !exists {
@@ -268,7 +471,8 @@ func (r *Result) AddAlias(ob *ast.Object) (*AliasDefinition, error) {
 		for _, value := range r.MaybeAliasses {
 			// xxx:
 			if ob.Name == value.TypeName {
-				r.AddAliasValue(value.rawDef)
+				item.Candidates = append(item.Candidates, value)
+				item.rawCandidates = append(item.rawCandidates, value.rawDef)
 			} else {
 				newGuesses = append(newGuesses, value)
 			}
@@ -278,122 +482,31 @@ func (r *Result) AddAlias(ob *ast.Object) (*AliasDefinition, error) {
 	return item, nil
 }
 
-type callexprVisitor struct {
-	Found *ast.CallExpr
-}
-
-func (v *callexprVisitor) Visit(node ast.Node) ast.Visitor {
-	if v.Found == nil && node != nil {
-		if callexpr, ok := node.(*ast.CallExpr); ok {
-			v.Found = callexpr
-		}
-	}
-	return v
-}
-
-func findCallExpr(val ast.Node) (*ast.CallExpr, error) {
-	v := &callexprVisitor{}
-	ast.Walk(v, val)
-	return v.Found, nil
-}
-
-type basicLitVisitor struct {
-	Found *ast.BasicLit
-}
-
-func (v *basicLitVisitor) Visit(node ast.Node) ast.Visitor {
-	if v.Found == nil && node != nil {
-		if basicLit, ok := node.(*ast.BasicLit); ok {
-			v.Found = basicLit
-		}
-	}
-	return v
-}
-
-func findBasicLit(val ast.Node) (*ast.BasicLit, error) {
-	v := &basicLitVisitor{}
-	ast.Walk(v, val)
-	if v.Found == nil {
-		return nil, fmt.Errorf("basicLit is not found")
-	}
-	return v.Found, nil
-}
-
-func findValueType(val ast.Node) (*ast.CallExpr, error) {
-	v := &callexprVisitor{}
-	ast.Walk(v, val)
-	if v.Found == nil {
-		return nil, fmt.Errorf("callExpr is not found")
-	}
-	return v.Found, nil
-}
-
 func (r *Result) AddAliasValue(ob *ast.Object) (*AliasDefinition, error) {
-	spec := ob.Decl.(*ast.ValueSpec)
-	values := spec.Values
-	if len(values) != 1 {
-		// iota
+	result, err := r.evalConst(ob)
+	if err != nil {
+		r.addDiagnostic(SeverityInfo, ob.Decl.(ast.Node), fmt.Sprintf("const %s is complex definition. skip.. (%s)", ob.Name, err))
 		return nil, nil
-		// return nil, fmt.Errorf("hmm:%v", values) // xxx;
-	}
-	var value *AliasValue
-	switch node := spec.Type.(type) {
-	case *ast.Ident:
-		typeName := node.Name
-		for _, v := range values {
-			lit, err := findBasicLit(v)
-			if err != nil {
-				if ident, ok := v.(*ast.Ident); ok && ident.Name == "iota" {
-					// iota
-					return nil, nil
-				}
-				fmt.Fprintf(os.Stderr, "const %s is complex definition. skip..\n", ob.Name)
-				return nil, nil
-			}
-			if lit == nil {
-				return nil, fmt.Errorf("not found: %s", ob.Name)
-			}
-			r.i++
-			value = &AliasValue{TypeName: typeName, Name: ob.Name, Value: lit.Value, rawDef: ob, i: r.i}
-			break
-		}
-	case nil:
-		// finding type name
-		for _, v := range values {
-			f, err := findCallExpr(v)
-			if err != nil {
-				return nil, err // xxx;
-			}
-			if f == nil {
-				if _, ok := v.(*ast.BasicLit); ok {
-					return nil, nil
-				}
-				fmt.Fprintf(os.Stderr, "const %s is complex definition. skip..\n", ob.Name)
-				return nil, nil
-			}
-			lit, err := findBasicLit(f)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "const %s is complex definition. skip..\n", ob.Name)
-				return nil, nil
-			}
-			if lit == nil {
-				return nil, fmt.Errorf("not found@@: %s", ob.Name)
-			}
-			fident, ok := f.Fun.(*ast.Ident)
-			if !ok {
-				fmt.Fprintf(os.Stderr, "const %s is complex definition. skip..\n", ob.Name)
-				return nil, nil
-			}
-			r.i++
-			value = &AliasValue{TypeName: fident.Name, Name: ob.Name, Value: lit.Value, rawDef: ob, i: r.i}
-			break
-		}
 	}
-	// fident.Obj is same as type def?
-	if value == nil {
-		fmt.Fprintf(os.Stderr, "const %s is complex definition. skip..\n", ob.Name)
+	if result.TypeName == "" {
+		// untyped const
 		return nil, nil
 	}
+	value := &AliasValue{
+		TypeName: result.TypeName,
+		Name:     ob.Name,
+		Exported: token.IsExported(ob.Name),
+		Value:    constString(result.Value),
+		Kind:     constKind(result.Value),
+		Computed: constValue(result.Value),
+		rawDef:   ob,
+		Doc:      r.findDoc(ob.Decl.(*ast.ValueSpec), ob.Decl.(*ast.ValueSpec).Doc),
+		Comment:  commentText(ob.Decl.(*ast.ValueSpec).Comment),
+		Pos:      r.findPos(ob.Pos(), ob.Decl.(*ast.ValueSpec).End()),
+		Index:    r.i,
+		file:     r.Name,
+	}
+	r.i++
 	if _, ok := r.AliasMap[value.TypeName]; !ok {
 		r.MaybeAliasses = append(r.MaybeAliasses, value)
 		return nil, nil
@@ -409,32 +522,152 @@ func (r *Result) AddAliasValue(ob *ast.Object) (*AliasDefinition, error) {
 	return item, nil
 }
 
+// addCandidates adds the consts whose types are not declared in the same file (Result.MaybeAliasses),
+// to the candidates of the types declared in the other files of m.
+// a const whose type is not found is reported, unless the type is builtin or excluded by the type visibility.
+func (m *Module) addCandidates(results []*Result) Diagnostics {
+	var ds Diagnostics
+	for _, r := range results {
+		for _, value := range r.MaybeAliasses {
+			_, def := m.LookupAlias(value.TypeName)
+			if def == nil {
+				if _, builtin := types.Universe.Lookup(value.TypeName).(*types.TypeName); !builtin && r.typeVisibility.includes(value.TypeName) {
+					ds.Add(SeverityWarning, "ValueSpec", value.Pos, fmt.Sprintf("const %s: type %s is not found in package %s", value.Name, value.TypeName, m.Name))
+				}
+				continue
+			}
+			def.Candidates = append(def.Candidates, value)
+			def.rawCandidates = append(def.rawCandidates, value.rawDef)
+			sort.Sort(indexAliasValues(def.Candidates))
+		}
+		r.MaybeAliasses = r.MaybeAliasses[:0]
+	}
+	return ds
+}
+
 type StructDefinition struct {
-	Name   string `json:"name"`
-	rawDef *ast.Object
-	Fields map[string]*Field `json:"fields"`
+	Name       string       `json:"name"`
+	Exported   bool         `json:"exported"`
+	Alias      bool         `json:"alias"` // type alias of struct literal (type A = struct{...})
+	Index      int          `json:"index"` // declaration order in file
+	TypeParams []*TypeParam `json:"typeparams,omitempty"`
+	Doc        string       `json:"doc,omitempty"`
+	Comment    string       `json:"comment,omitempty"` // line comment
+	Pos        *Pos         `json:"pos,omitempty"`
+	rawDef     *ast.Object
+	Fields     map[string]*Field `json:"fields"`
+	WireFields []*WireField      `json:"wirefields,omitempty"` // fields of encoded JSON object, see World.ComputeWireFields
+	Promoted   []*PromotedField  `json:"promoted,omitempty"`   // flattened fields with embedded ones, see World.ComputePromotedFields
+
+	hiddenEmbeds []*Field // unexported embedded fields excluded by the field visibility, to promote their fields
+
+	Methods          []*MethodDefinition `json:"methods,omitempty"`          // methods declared on the type
+	MethodSet        []*MethodSetEntry   `json:"methodset,omitempty"`        // method set of the type, see World.ComputeMethodSets
+	PointerMethodSet []*MethodSetEntry   `json:"pointermethodset,omitempty"` // method set of the pointer type
 }
 
 type InterfaceDefinition struct {
-	Name   string `json:"name"`
-	rawDef *ast.Object
-	// TODO: methods
+	Name       string       `json:"name"`
+	Exported   bool         `json:"exported"`
+	Alias      bool         `json:"alias"` // type alias of interface literal (type A = interface{...})
+	Index      int          `json:"index"` // declaration order in file
+	TypeParams []*TypeParam `json:"typeparams,omitempty"`
+	Doc        string       `json:"doc,omitempty"`
+	Comment    string       `json:"comment,omitempty"` // line comment
+	Pos        *Pos         `json:"pos,omitempty"`
+	rawDef     *ast.Object
+	Methods    []*Method `json:"methods"`
+	Embeds     []Type    `json:"embeds,omitempty"`  // embedded interfaces (e.g. io.Reader)
+	TypeSet    []Type    `json:"typeset,omitempty"` // type-set terms of constraint (e.g. ~int | ~string)
+}
+
+// Method is a method signature.
+type Method struct {
+	Name     string   `json:"name"`
+	Exported bool     `json:"exported"`
+	Params   []*Param `json:"params"`
+	Results  []*Param `json:"results"`
+	Variadic bool     `json:"variadic"` // the last param is variadic (e.g. ...string)
+	Doc      string   `json:"doc,omitempty"`
+	Comment  string   `json:"comment,omitempty"` // line comment
+	Pos      *Pos     `json:"pos,omitempty"`
+}
+
+// Param is a parameter or a result of a function. Name is empty if unnamed.
+type Param struct {
+	Name string `json:"name,omitempty"`
+	Type Type   `json:"type"`
 }
 
 type AliasDefinition struct {
 	Name          string        `json:"name"`
+	Exported      bool          `json:"exported"`
+	Alias         bool          `json:"alias"` // true alias (type A = B), otherwise new defined type (type A B)
+	Index         int           `json:"index"` // declaration order in file
+	TypeParams    []*TypeParam  `json:"typeparams,omitempty"`
 	Original      Type          `json:"original"`
-	Candidates    []*AliasValue `json:"candidates"`
+	Kind          string        `json:"kind,omitempty"`   // kind of the underlying type (e.g. "func", "struct"), see World.ComputeAliasKinds
+	Target        *AliasTarget  `json:"target,omitempty"` // definition referred by the true alias, see World.ComputeAliasTargets
+	Candidates    []*AliasValue `json:"candidates"`       // in declaration order
+	Doc           string        `json:"doc,omitempty"`
+	Comment       string        `json:"comment,omitempty"` // line comment
+	Pos           *Pos          `json:"pos,omitempty"`
 	rawDef        *ast.Object
 	rawCandidates []*ast.Object
+
+	Methods          []*MethodDefinition `json:"methods,omitempty"`          // methods declared on the type
+	MethodSet        []*MethodSetEntry   `json:"methodset,omitempty"`        // method set of the type, see World.ComputeMethodSets
+	PointerMethodSet []*MethodSetEntry   `json:"pointermethodset,omitempty"` // method set of the pointer type
+}
+
+// TypeParam is a type parameter of a generic type definition (e.g. T any).
+type TypeParam struct {
+	Name       string `json:"name"`
+	Constraint Type   `json:"constraint"`
+	Pos        *Pos   `json:"pos,omitempty"`
 }
 
 type AliasValue struct {
-	TypeName string `json:"-"`
-	i        int
+	TypeName string      `json:"-"`
 	Name     string      `json:"name"`
-	Value    interface{} `json:"value"`
+	Exported bool        `json:"exported"`
+	Index    int         `json:"index"`    // declaration order in file
+	Value    interface{} `json:"value"`    // exact representation (e.g. "\"female\"", "1")
+	Kind     string      `json:"kind"`     // kind of constant (bool, string, int, float, complex)
+	Computed interface{} `json:"computed"` // evaluated value
+	Doc      string      `json:"doc,omitempty"`
+	Comment  string      `json:"comment,omitempty"` // line comment
+	Pos      *Pos        `json:"pos,omitempty"`
 	rawDef   *ast.Object
+	file     string // name of the file declaring the value
+}
+
+// FuncDefinition is a function declaration.
+type FuncDefinition struct {
+	Name     string   `json:"name"`
+	Exported bool     `json:"exported"`
+	Params   []*Param `json:"params"`
+	Returns  []*Param `json:"returns"`
+	Variadic bool     `json:"variadic"` // the last param is variadic (e.g. ...string)
+	Doc      string   `json:"doc,omitempty"`
+	Pos      *Pos     `json:"pos,omitempty"`
+}
+
+func (r *Result) AddFunc(decl *ast.FuncDecl) (*FuncDefinition, error) {
+	item := &FuncDefinition{
+		Name:     decl.Name.Name,
+		Exported: token.IsExported(decl.Name.Name),
+		Params:   findParams(r, decl.Type.Params),
+		Returns:  findParams(r, decl.Type.Results),
+		Variadic: isVariadic(decl.Type),
+		Doc:      commentText(decl.Doc),
+		Pos:      r.findPos(decl.Pos(), decl.End()),
+	}
+	if r.FuncMap == nil {
+		r.FuncMap = make(map[string]*FuncDefinition)
+	}
+	r.FuncMap[item.Name] = item
+	return item, nil
 }
 
 type ImportDefinition struct {
@@ -467,8 +700,33 @@ func CollectImports(imports []*ast.ImportSpec) map[string]*ImportDefinition {
 
 func CollectResult(name string, scope *ast.Scope, imports []*ast.ImportSpec) (*Result, error) {
 	r := NewResult(name)
+	return r.collect(scope, imports, nil)
+}
+
+func (r *Result) collect(scope *ast.Scope, imports []*ast.ImportSpec, decls []ast.Decl) (*Result, error) {
 	r.ImportsMap = CollectImports(imports)
+	if r.consts == nil {
+		r.consts = newConstEvaluator(decls)
+	}
+	r.genDecls = collectGenDecls(decls)
+
+	// in declaration order
+	objects := make([]*ast.Object, 0, len(scope.Objects))
 	for _, ob := range scope.Objects {
+		objects = append(objects, ob)
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Pos() < objects[j].Pos() })
+	for _, ob := range objects {
+		// skip if unexported type.
+		if ob.Kind == ast.Typ && !r.typeVisibility.includes(ob.Name) {
+			r.hiddenTypes[ob.Name] = true
+			if isStructDefinition(ob) {
+				if _, err := r.addHiddenStruct(ob); err != nil {
+					return r, err
+				}
+			}
+			continue
+		}
 		anyFound := false
 		if isStructDefinition(ob) {
 			anyFound = true
@@ -505,7 +763,22 @@ func CollectResult(name string, scope *ast.Scope, imports []*ast.ImportSpec) (*R
 		}
 	}
 	for _, alias := range r.AliasMap {
-		sort.Sort(nameAliasValues(alias.Candidates))
+		sort.Sort(indexAliasValues(alias.Candidates))
+	}
+	for _, decl := range decls {
+		decl, ok := decl.(*ast.FuncDecl)
+		if !ok || !r.typeVisibility.includes(decl.Name.Name) {
+			continue
+		}
+		if decl.Recv != nil && len(decl.Recv.List) > 0 {
+			if _, err := r.AddMethod(decl); err != nil {
+				return r, err
+			}
+		} else if r.FuncMap != nil {
+			if _, err := r.AddFunc(decl); err != nil {
+				return r, err
+			}
+		}
 	}
 	return r, nil
 }
@@ -520,11 +793,6 @@ func isInterfaceDefinition(ob *ast.Object) bool {
 		return false
 	}
 
-	// skip if unexported struct.
-	if strings.Title(node.Name.Name) != node.Name.Name {
-		return false
-	}
-
 	_, ok = node.Type.(*ast.InterfaceType)
 	if !ok {
 		return false
@@ -542,11 +810,6 @@ func isStructDefinition(ob *ast.Object) bool {
 		return false
 	}
 
-	// skip if unexported struct.
-	if strings.Title(node.Name.Name) != node.Name.Name {
-		return false
-	}
-
 	_, ok = node.Type.(*ast.StructType)
 	if !ok {
 		return false
@@ -562,16 +825,13 @@ func isAliasDefinition(ob *ast.Object) bool {
 	if !ok {
 		return false
 	}
-	// skip if unexported alias.
-	if strings.Title(node.Name.Name) != node.Name.Name {
-		return false
-	}
 
+	// other than struct and interface (e.g. func, chan, named types)
 	switch node.Type.(type) {
-	case *ast.Ident, *ast.SelectorExpr, *ast.ArrayType, *ast.MapType, *ast.StarExpr:
-		return true
-	default:
+	case *ast.StructType, *ast.InterfaceType:
 		return false
+	default:
+		return true
 	}
 }
 
@@ -593,9 +853,9 @@ func CollectPackageMap(fpath string) (map[string]*ast.Package, error) {
 	}
 	fset := token.NewFileSet()
 	if stat.IsDir() {
-		return parser.ParseDir(fset, fpath, nil, 0)
+		return parser.ParseDir(fset, fpath, nil, parser.ParseComments)
 	}
-	f, err := parser.ParseFile(fset, fpath, nil, 0)
+	f, err := parser.ParseFile(fset, fpath, nil, parser.ParseComments)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,360 @@
+package structjson
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// JSONField is the effective encoding/json behavior of a field.
+type JSONField struct {
+	Name      string `json:"name"`      // effective name
+	Tagged    bool   `json:"tagged"`    // name is given by the tag
+	Omitted   bool   `json:"omitted"`   // never encoded (json:"-" or unexported)
+	OmitEmpty bool   `json:"omitempty"` // ,omitempty
+	String    bool   `json:"string"`    // ,string, if the type may be quoted (see quotable)
+	Inline    bool   `json:"inline"`    // embedded without name, the fields are promoted if it is a struct
+}
+
+// WireField is a field of the JSON object encoded from a struct, after promotion and conflict resolution.
+type WireField struct {
+	Name         string `json:"name"`   // json name
+	Field        string `json:"field"`  // go field name
+	Origin       string `json:"origin"` // struct declaring the field, with the full package name (e.g. "github.com/podhmo/go-structjson/examples/models.Person")
+	Index        []int  `json:"index"`  // index sequence through embedded structs
+	Type         Type   `json:"type"`
+	OmitEmpty    bool   `json:"omitempty"`
+	String       bool   `json:"string"`
+	CaseConflict bool   `json:"caseconflict,omitempty"` // other field has the same name, case-insensitively
+	Indirect     bool   `json:"indirect,omitempty"`     // promoted through an embedded pointer, not encoded if it is nil
+	tagged       bool
+}
+
+func newJSONField(f *Field) *JSONField {
+	jf := &JSONField{Name: f.Name}
+	if !f.Embed && !token.IsExported(f.Name) {
+		jf.Omitted = true
+		return jf
+	}
+	tag, ok := f.TagValues["json"]
+	if ok && tag == "-" {
+		jf.Omitted = true
+		return jf
+	}
+	name, opts := tag, ""
+	if i := strings.Index(tag, ","); i >= 0 {
+		name, opts = tag[:i], tag[i+1:]
+	}
+	if isValidJSONName(name) {
+		jf.Name = name
+		jf.Tagged = true
+	}
+	for _, opt := range strings.Split(opts, ",") {
+		switch opt {
+		case "omitempty":
+			jf.OmitEmpty = true
+		case "string":
+			jf.String = quotable(unpointer(f.Type))
+		}
+	}
+	jf.Inline = f.Embed && !jf.Tagged
+	return jf
+}
+
+// quotedKinds is the underlying types quoted by the option ,string of encoding/json.
+var quotedKinds = map[string]bool{
+	"bool": true, "string": true, "float32": true, "float64": true, "uintptr": true, "byte": true, "rune": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true,
+}
+
+// quotable reports whether typ may be quoted by the option ,string (a boolean, a number or a string).
+// a named type whose underlying type is not known is quotable, see World.quoted.
+func quotable(typ Type) bool {
+	switch t := typ.(type) {
+	case *PrimitiveType:
+		if t.TypeInfo != nil {
+			return quotedKinds[t.TypeInfo.Underlying]
+		}
+		return !isBuiltin(t.Value) || quotedKinds[t.Value]
+	case *SelectorType:
+		return t.TypeInfo == nil || quotedKinds[t.TypeInfo.Underlying]
+	case *InstantiationType:
+		return true
+	default:
+		return false
+	}
+}
+
+// unpointer returns the element type if typ is a pointer type, encoding/json dereferences it once for the option ,string.
+func unpointer(typ Type) Type {
+	if t, ok := typ.(*PointerType); ok {
+		return t.Value
+	}
+	return typ
+}
+
+// quoted reports whether the option ,string is applied to the field of typ (referenced in r of m), following the named types in w.
+// a named type not found in w is assumed to be quoted.
+func (w *World) quoted(m *Module, r *Result, typ Type) bool {
+	typ = unpointer(typ)
+	seen := map[*AliasDefinition]bool{}
+	for quotable(typ) {
+		if t, ok := typ.(*PrimitiveType); ok && (t.TypeInfo != nil || isBuiltin(t.Value)) {
+			return true
+		}
+		ref := w.lookupNamed(m, r, typ)
+		if ref == nil {
+			return true
+		}
+		ar, def := ref.Module.LookupAlias(ref.Name)
+		if def == nil || seen[def] {
+			return w.resolveEmbedded(m, r, typ) == nil // not a struct
+		}
+		seen[def] = true
+		m, r, typ = ref.Module, ar, def.Original
+	}
+	return false
+}
+
+// isBuiltin reports whether name is a predeclared type (e.g. error).
+func isBuiltin(name string) bool {
+	_, ok := types.Universe.Lookup(name).(*types.TypeName)
+	return ok
+}
+
+// isValidJSONName is same as encoding/json's isValidTag.
+func isValidJSONName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		switch {
+		case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c):
+			// Backslash and quote chars are reserved, but
+			// otherwise any punctuation chars are allowed
+			// in a tag name.
+		case !unicode.IsLetter(c) && !unicode.IsDigit(c):
+			return false
+		}
+	}
+	return true
+}
+
+// ComputeWireFields computes WireFields of all structs in w,
+// with the embedded struct promotion and the conflict resolution of encoding/json.
+// an unexported embedded type not declared in w cannot be followed (it may be a struct), it is reported in w.Diagnostics.
+func (w *World) ComputeWireFields() {
+	unresolved := map[*Field]bool{}
+	for _, m := range w.Modules {
+		for _, r := range m.Files {
+			for _, def := range r.StructMap {
+				def.WireFields = w.wireFields(&structRef{Module: m, Result: r, Def: def}, unresolved)
+			}
+		}
+	}
+	fields := make([]*Field, 0, len(unresolved))
+	for f := range unresolved {
+		fields = append(fields, f)
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		x, y := fields[i].Pos, fields[j].Pos
+		if x == nil || y == nil {
+			return x == nil && y != nil
+		}
+		if x.Filename != y.Filename {
+			return x.Filename < y.Filename
+		}
+		return x.Offset < y.Offset
+	})
+	for _, f := range fields {
+		w.Diagnostics.Add(SeverityWarning, "Field", f.Pos, fmt.Sprintf("%s: embedded type is not found, the fields promoted through it are missing", f.Name))
+	}
+}
+
+// LookupOrigin returns the struct declaring a field, from WireField.Origin or PromotedField.Origin.
+// the struct excluded by the type visibility is also found.
+func (w *World) LookupOrigin(origin string) (*Module, *Result, *StructDefinition) {
+	i := strings.LastIndex(origin, ".")
+	if i < 0 {
+		return nil, nil, nil
+	}
+	m := w.LookupModule(origin[:i])
+	if m == nil {
+		return nil, nil, nil
+	}
+	if r, def := m.LookupStruct(origin[i+1:]); def != nil {
+		return m, r, def
+	}
+	r, def := m.lookupHiddenStruct(origin[i+1:])
+	return m, r, def
+}
+
+// wireFields is a port of encoding/json's typeFields.
+// the unexported embedded fields whose types are not found are added to unresolved.
+func (w *World) wireFields(root *structRef, unresolved map[*Field]bool) []*WireField {
+	type item struct {
+		ref      *structRef
+		index    []int
+		indirect bool
+	}
+
+	current := []item{}
+	next := []item{{ref: root}}
+	count := map[*StructDefinition]int{}
+	nextCount := map[*StructDefinition]int{}
+	visited := map[*StructDefinition]bool{}
+
+	var fields []*WireField
+	for len(next) > 0 {
+		current, next = next, current[:0]
+		count, nextCount = nextCount, map[*StructDefinition]int{}
+
+		for _, it := range current {
+			if visited[it.ref.Def] {
+				continue
+			}
+			visited[it.ref.Def] = true
+
+			for _, sf := range sortedFields(it.ref.Def) {
+				var embedded *structRef
+				if sf.Embed {
+					embedded = w.resolveEmbedded(it.ref.Module, it.ref.Result, sf.Type)
+					if !token.IsExported(sf.Name) && embedded == nil {
+						// ignore embedded fields of unexported non-struct types.
+						typ := sf.Type
+						if t, ok := typ.(*PointerType); ok {
+							typ = t.Value
+						}
+						if ref := w.lookupNamed(it.ref.Module, it.ref.Result, typ); ref == nil || !ref.Module.declares(ref.Name) && !isBuiltin(ref.Name) {
+							unresolved[sf] = true // may be a struct
+						}
+						continue
+					}
+				} else if !token.IsExported(sf.Name) {
+					continue
+				}
+				jf := sf.JSON
+				if jf == nil {
+					jf = newJSONField(sf)
+				}
+				if jf.Omitted {
+					continue
+				}
+				index := make([]int, len(it.index)+1)
+				copy(index, it.index)
+				index[len(it.index)] = sf.Index
+
+				// record found field and index sequence.
+				if jf.Tagged || !sf.Embed || embedded == nil {
+					field := &WireField{
+						Name:      jf.Name,
+						Field:     sf.Name,
+						Origin:    it.ref.Module.FullName + "." + it.ref.Def.Name,
+						Index:     index,
+						Type:      sf.Type,
+						OmitEmpty: jf.OmitEmpty,
+						String:    jf.String && w.quoted(it.ref.Module, it.ref.Result, sf.Type),
+						Indirect:  it.indirect,
+						tagged:    jf.Tagged,
+					}
+					fields = append(fields, field)
+					if count[it.ref.Def] > 1 {
+						// if there were multiple instances, add a second,
+						// so that the annihilation code will see a duplicate.
+						fields = append(fields, fields[len(fields)-1])
+					}
+					continue
+				}
+
+				// record new anonymous struct to explore in next round.
+				nextCount[embedded.Def]++
+				if nextCount[embedded.Def] == 1 {
+					_, pointer := sf.Type.(*PointerType)
+					next = append(next, item{ref: embedded, index: index, indirect: it.indirect || pointer})
+				}
+			}
+		}
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		x := fields
+		// sort field by name, breaking ties with depth, then
+		// breaking ties with "name came from json tag", then
+		// breaking ties with index sequence.
+		if x[i].Name != x[j].Name {
+			return x[i].Name < x[j].Name
+		}
+		if len(x[i].Index) != len(x[j].Index) {
+			return len(x[i].Index) < len(x[j].Index)
+		}
+		if x[i].tagged != x[j].tagged {
+			return x[i].tagged
+		}
+		return compareIndex(x[i].Index, x[j].Index) < 0
+	})
+
+	// delete all fields that are hidden by the Go rules for embedded fields,
+	// except that fields with JSON tags are promoted.
+	out := fields[:0]
+	for advance, i := 0, 0; i < len(fields); i += advance {
+		fi := fields[i]
+		for advance = 1; i+advance < len(fields); advance++ {
+			if fields[i+advance].Name != fi.Name {
+				break
+			}
+		}
+		if advance == 1 {
+			out = append(out, fi)
+			continue
+		}
+		if dominant, ok := dominantWireField(fields[i : i+advance]); ok {
+			out = append(out, dominant)
+		}
+	}
+	fields = out
+	sort.Slice(fields, func(i, j int) bool { return compareIndex(fields[i].Index, fields[j].Index) < 0 })
+
+	for i, f := range fields {
+		for j, g := range fields {
+			if i != j && strings.EqualFold(f.Name, g.Name) {
+				f.CaseConflict = true
+			}
+		}
+	}
+	return fields
+}
+
+// dominantWireField looks through the fields, all of which are known to
+// have the same name, to find the single field that dominates the
+// others using Go's embedding rules, modified by the presence of
+// JSON tags. if there are multiple top-level fields, the boolean
+// will be false: this condition is an error in Go and we skip all
+// the fields.
+func dominantWireField(fields []*WireField) (*WireField, bool) {
+	if len(fields) > 1 && len(fields[0].Index) == len(fields[1].Index) && fields[0].tagged == fields[1].tagged {
+		return nil, false
+	}
+	return fields[0], true
+}
+
+func compareIndex(x, y []int) int {
+	for k, xik := range x {
+		if k >= len(y) {
+			return 1
+		}
+		if xik != y[k] {
+			if xik < y[k] {
+				return -1
+			}
+			return 1
+		}
+	}
+	if len(x) < len(y) {
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,89 @@
+package structjson
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// wireNames returns the wire fields of the struct named name, as "<json name>:<origin without the package path>"
+// with the suffix "*" if indirect, "?" if omitempty, "$" if quoted by ,string and "~" if case conflicted.
+func wireNames(m *Module, name string) []string {
+	_, def := m.LookupStruct(name)
+	if def == nil {
+		return nil
+	}
+	var names []string
+	for _, wf := range def.WireFields {
+		s := wf.Name + ":" + strings.TrimPrefix(wf.Origin, "github.com/podhmo/go-structjson/testdata/")
+		if wf.Indirect {
+			s += "*"
+		}
+		if wf.OmitEmpty {
+			s += "?"
+		}
+		if wf.String {
+			s += "$"
+		}
+		if wf.CaseConflict {
+			s += "~"
+		}
+		names = append(names, s)
+	}
+	return names
+}
+
+func TestWireFields(t *testing.T) {
+	w, m := loadTestdata(t, "wirefields")
+	admin := []string{
+		"id:wirefields.Base*", // createdAt and note are conflicted between Base and Audit
+		"updatedAt:wirefields.Audit",
+		"group:wirefields.Admin",
+		"level:wirefields.Admin$",
+		"-:wirefields.Admin",
+		"Name:wirefields.Admin~",
+		"name:wirefields.Admin~",
+	}
+	cases := []struct {
+		msg  string
+		name string
+		want []string
+	}{
+		{msg: "plain", name: "Base", want: []string{"id:wirefields.Base", "createdAt:wirefields.Base?", "note:wirefields.Base"}},
+		{msg: "conflicts and pointer embed", name: "Admin", want: admin},
+		{msg: "through embedded struct", name: "Deep", want: admin},
+		{msg: "unexported embed", name: "Item", want: []string{"id:wirefields.base", "kind:wirefields.base", "name:wirefields.Item"}},
+		{msg: "ambiguous at same depth", name: "Ambiguous", want: []string{"Own:wirefields.Ambiguous"}},
+		{msg: "shallower wins", name: "Shadowed", want: []string{"X:wirefields.Shadowed"}},
+		{msg: "tagged wins", name: "TagWins", want: []string{"X:wirefields.XT"}},
+		{msg: "unexported non-struct embed", name: "Ignored", want: []string{"Name:wirefields.Ignored"}},
+		{msg: "unresolved embed", name: "Missing", want: []string{"Name:wirefields.Missing"}},
+		{
+			msg:  "string option only for booleans, numbers and strings",
+			name: "Quoted",
+			want: []string{
+				"Count:wirefields.Quoted$",
+				"Ptr:wirefields.Quoted$",
+				"Level:wirefields.Quoted$",
+				"Tags:wirefields.Quoted",
+				"Names:wirefields.Quoted",
+				"Base:wirefields.Quoted",
+				"PP:wirefields.Quoted",
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.msg, func(t *testing.T) {
+			if got := wireNames(m, c.name); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("%s: wire fields\nwant %q\n got %q", c.name, c.want, got)
+			}
+		})
+	}
+
+	t.Run("diagnostics", func(t *testing.T) {
+		want := []string{"missing: embedded type is not found, the fields promoted through it are missing"}
+		if got := messages(w.Diagnostics); !reflect.DeepEqual(got, want) {
+			t.Errorf("diagnostics\nwant %q\n got %q", want, got)
+		}
+	})
+}
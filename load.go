@@ -0,0 +1,252 @@
+package structjson
+
+import (
+	"context"
+	"go/ast"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// DefaultExclude is the packages excluded by default (mainly, the standard packages not including definitions for JSON).
+var DefaultExclude = []string{
+	"fmt", "log", "reflect", "go/ast", "unsafe", "html/template", "text/template", "encoding/xml", "syscall", "windows",
+	"encoding/binary", "sync", "os", "flag", "net/http", "go/format", "encoding/json", "sys", "bufio", "bytes/buffer",
+	"unicode", "sync/atomic",
+}
+
+// Config is the configuration of Load.
+type Config struct {
+	Dir      string   // the directory in which patterns are resolved ("" is the current directory)
+	Patterns []string // package patterns (e.g. "./...", "example.com/x/models")
+
+	// Include is the package paths to be collected. if empty, all packages are collected.
+	// a path ending with "/..." matches the path itself and all packages under it.
+	Include []string
+	// Exclude is the package paths not to be collected, in the same syntax as Include.
+	Exclude []string
+	// Depth is the depth of recursion into imported packages. 0 is only the packages matching Patterns, negative is unlimited.
+	Depth int
+
+	Tests        bool // _test.go files are also collected
+	Main         bool // main packages are also collected
+	Typed        bool // type references are enriched with go/types information
+	RelativePath bool // filenames of positions are relative to the module root
+	Funcs        bool // functions are also collected (Result.FuncMap)
+
+	TypeVisibility  Visibility // of types and functions (default: VisibilityExported)
+	FieldVisibility Visibility // of struct fields (default: VisibilityAll)
+
+	Logf func(format string, args ...interface{}) // if not nil, progress is logged
+}
+
+// NewConfig returns the default configuration, unlimited depth and excluding DefaultExclude.
+func NewConfig(patterns ...string) *Config {
+	return &Config{
+		Patterns: patterns,
+		Depth:    -1,
+		Exclude:  append([]string(nil), DefaultExclude...),
+	}
+}
+
+// Load loads the packages matching config.Patterns and the packages they depend on,
+// and collects their definitions into a World. alias targets and kinds, wire fields, promoted fields and method sets are also computed.
+// problems found in the packages are not errors, they are reported in World.Diagnostics.
+func Load(ctx context.Context, config Config) (*World, error) {
+	mode := LoadMode
+	if config.Typed {
+		mode = TypedLoadMode
+	}
+	pkgs, err := packages.Load(&packages.Config{
+		Context: ctx,
+		Mode:    mode,
+		Dir:     config.Dir,
+		Tests:   config.Tests,
+	}, config.Patterns...)
+	if err != nil {
+		return nil, err
+	}
+
+	// with Tests, a package is loaded twice (with and without _test.go files). the one having more files is used.
+	sort.SliceStable(pkgs, func(i, j int) bool {
+		if pkgs[i].PkgPath != pkgs[j].PkgPath {
+			return pkgs[i].PkgPath < pkgs[j].PkgPath
+		}
+		return len(pkgs[i].Syntax) > len(pkgs[j].Syntax)
+	})
+
+	l := &loader{
+		config: &config,
+		options: &CollectOptions{
+			RelativePath:    config.RelativePath,
+			Funcs:           config.Funcs,
+			TypeVisibility:  config.TypeVisibility,
+			FieldVisibility: config.FieldVisibility,
+		},
+		used: map[string]struct{}{},
+	}
+	world := NewWorld()
+
+	// breadth first, so that a package is collected at its minimum depth, and the packages matching the patterns are roots.
+	type item struct {
+		pkg   *packages.Package
+		depth int
+	}
+	queue := make([]item, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		queue = append(queue, item{pkg: pkg})
+	}
+	for len(queue) > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		it := queue[0]
+		queue = queue[1:]
+		deps, err := l.parse(world, it.pkg, it.depth)
+		if err != nil {
+			return nil, err
+		}
+		for _, dep := range deps {
+			queue = append(queue, item{pkg: dep, depth: it.depth + 1})
+		}
+	}
+	world.ComputeAliasTargets()
+	world.ComputeAliasKinds()
+	world.ComputeWireFields()
+	world.ComputePromotedFields()
+	world.ComputeMethodSets()
+	return world, nil
+}
+
+type loader struct {
+	config  *Config
+	options *CollectOptions
+	used    map[string]struct{} // package path
+}
+
+func (l *loader) logf(depth int, format string, args ...interface{}) {
+	if l.config.Logf == nil {
+		return
+	}
+	l.config.Logf(strings.Repeat(" ", depth)+format, args...)
+}
+
+// parse collects the definitions of pkg into world, and returns the imported packages to be collected next, sorted by path.
+func (l *loader) parse(world *World, pkg *packages.Package, depth int) ([]*packages.Package, error) {
+	if _, exists := l.used[pkg.PkgPath]; exists {
+		return nil, nil
+	}
+	l.used[pkg.PkgPath] = struct{}{}
+
+	if !l.match(pkg.PkgPath) {
+		l.logf(depth, "parse: skip %q", pkg.PkgPath)
+		return nil, nil
+	}
+	l.logf(depth, "parse: %q", pkg.PkgPath)
+	for _, err := range pkg.Errors {
+		world.Diagnostics.Add(SeverityWarning, "Package", nil, err.Error())
+	}
+
+	// skip main (and generated test main)
+	if pkg.Name == "main" && (!l.config.Main || strings.HasSuffix(pkg.ID, ".test")) {
+		return nil, nil
+	}
+
+	module := NewModule(pkg.Name)
+	module.FullName = pkg.PkgPath
+	world.Modules[pkg.PkgPath] = module
+	if depth == 0 {
+		world.Roots = append(world.Roots, pkg.PkgPath)
+	}
+
+	files := PackageFiles(pkg)
+	fileNameList := make([]string, 0, len(files))
+	for fname := range files {
+		fileNameList = append(fileNameList, fname)
+	}
+	sort.Strings(fileNameList)
+
+	// skip test code
+	if !l.config.Tests {
+		names := fileNameList[:0]
+		for _, fname := range fileNameList {
+			if !strings.HasSuffix(fname, "_test.go") {
+				names = append(names, fname)
+			}
+		}
+		fileNameList = names
+	}
+
+	// consts are evaluated across the files (e.g. a const referring to one declared in another file)
+	syntax := make([]*ast.File, len(fileNameList))
+	for i, fname := range fileNameList {
+		syntax[i] = files[fname]
+	}
+	consts := newConstEvaluator(packageDecls(syntax))
+
+	var results []*Result
+	deps := map[string]*packages.Package{}
+	for _, fname := range fileNameList {
+		result, err := collectFileResult(pkg, files[fname], l.options, consts)
+		if err != nil {
+			return nil, err
+		}
+		world.Diagnostics = append(world.Diagnostics, result.Diagnostics...)
+		results = append(results, result)
+		// skip no contents
+		if len(result.AliasMap) == 0 && len(result.StructMap) == 0 && len(result.InterfaceMap) == 0 && len(result.FuncMap) == 0 && len(result.Methods) == 0 {
+			if len(result.hiddenTypes) > 0 {
+				module.hiddenFiles[fname] = result
+			}
+			continue
+		}
+		module.Files[fname] = result
+		if l.config.Depth >= 0 && depth >= l.config.Depth {
+			continue
+		}
+		for _, im := range result.ImportsMap {
+			if !im.NeedParse {
+				continue
+			}
+			if dep, ok := pkg.Imports[im.FullName]; ok {
+				deps[dep.PkgPath] = dep
+			}
+		}
+	}
+	world.Diagnostics = append(world.Diagnostics, module.addCandidates(results)...)
+
+	sorted := make([]*packages.Package, 0, len(deps))
+	for _, dep := range deps {
+		sorted = append(sorted, dep)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].PkgPath < sorted[j].PkgPath })
+	return sorted, nil
+}
+
+// match reports whether the package is collected, with Include and Exclude.
+func (l *loader) match(pkgpath string) bool {
+	for _, pattern := range l.config.Exclude {
+		if matchPackage(pattern, pkgpath) {
+			return false
+		}
+	}
+	if len(l.config.Include) == 0 {
+		return true
+	}
+	for _, pattern := range l.config.Include {
+		if matchPackage(pattern, pkgpath) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPackage reports whether pkgpath matches pattern ("example.com/x" or "example.com/x/...").
+func matchPackage(pattern, pkgpath string) bool {
+	pattern = strings.TrimSpace(pattern)
+	if prefix := strings.TrimSuffix(pattern, "/..."); prefix != pattern {
+		return pkgpath == prefix || strings.HasPrefix(pkgpath, prefix+"/")
+	}
+	return pkgpath == pattern
+}
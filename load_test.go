@@ -0,0 +1,33 @@
+package structjson
+
+import (
+	"context"
+	"testing"
+)
+
+// loadTestdata loads the package testdata/<name> (with its dependencies), as the command does.
+func loadTestdata(t *testing.T, name string, options ...func(*Config)) (*World, *Module) {
+	t.Helper()
+	config := NewConfig("./testdata/" + name + "/")
+	for _, opt := range options {
+		opt(config)
+	}
+	w, err := Load(context.Background(), *config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := w.LookupModule("github.com/podhmo/go-structjson/testdata/" + name)
+	if m == nil {
+		t.Fatalf("module %s is not loaded", name)
+	}
+	return w, m
+}
+
+// messages returns the messages of the diagnostics.
+func messages(ds Diagnostics) []string {
+	var ms []string
+	for _, d := range ds {
+		ms = append(ms, d.Message)
+	}
+	return ms
+}
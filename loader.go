@@ -0,0 +1,89 @@
+package structjson
+
+import (
+	"go/ast"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// LoadMode is the mode used by LoadPackages.
+const LoadMode = packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedImports | packages.NeedDeps | packages.NeedModule
+
+// LoadPackages loads the packages matching patterns (e.g. "./...", "example.com/x/models").
+// imports are resolved with go.mod, the module cache and vendor directories, relative to dir.
+func LoadPackages(dir string, patterns ...string) ([]*packages.Package, error) {
+	cfg := &packages.Config{
+		Mode: LoadMode,
+		Dir:  dir,
+	}
+	return packages.Load(cfg, patterns...)
+}
+
+// PackageFiles returns the parsed files of pkg, keyed by filename.
+func PackageFiles(pkg *packages.Package) map[string]*ast.File {
+	files := make(map[string]*ast.File, len(pkg.Syntax))
+	for _, f := range pkg.Syntax {
+		files[pkg.Fset.File(f.Pos()).Name()] = f
+	}
+	return files
+}
+
+// TypedLoadMode is the mode used by LoadTypedPackages.
+const TypedLoadMode = LoadMode | packages.NeedTypes | packages.NeedTypesInfo
+
+// LoadTypedPackages is like LoadPackages, but the packages are also type-checked.
+func LoadTypedPackages(dir string, patterns ...string) ([]*packages.Package, error) {
+	cfg := &packages.Config{
+		Mode: TypedLoadMode,
+		Dir:  dir,
+	}
+	return packages.Load(cfg, patterns...)
+}
+
+// CollectOptions is the options of CollectFileResult.
+type CollectOptions struct {
+	RelativePath bool // filenames of positions are relative to the module root
+	Funcs        bool // functions are also collected (Result.FuncMap)
+
+	TypeVisibility  Visibility // of types and functions (default: VisibilityExported)
+	FieldVisibility Visibility // of struct fields (default: VisibilityAll)
+}
+
+// CollectFileResult collects definitions from f, a file of pkg.
+// when pkg is type-checked, type references are enriched with go/types information.
+// consts are evaluated with the declarations in all files of pkg.
+func CollectFileResult(pkg *packages.Package, f *ast.File, options *CollectOptions) (*Result, error) {
+	return collectFileResult(pkg, f, options, newConstEvaluator(packageDecls(pkg.Syntax)))
+}
+
+func collectFileResult(pkg *packages.Package, f *ast.File, options *CollectOptions, consts *constEvaluator) (*Result, error) {
+	if options == nil {
+		options = &CollectOptions{}
+	}
+	r := NewResult(pkg.Fset.File(f.Pos()).Name())
+	r.info = pkg.TypesInfo
+	r.consts = consts
+	r.fset = pkg.Fset
+	if options.RelativePath && pkg.Module != nil {
+		r.root = pkg.Module.Dir
+	}
+	if options.TypeVisibility != "" {
+		r.typeVisibility = options.TypeVisibility
+	}
+	if options.FieldVisibility != "" {
+		r.fieldVisibility = options.FieldVisibility
+	}
+	if options.Funcs {
+		r.FuncMap = map[string]*FuncDefinition{}
+	}
+	return r.collect(f.Scope, f.Imports, f.Decls)
+}
+
+// packageDecls returns the top-level declarations of the files.
+func packageDecls(files []*ast.File) []ast.Decl {
+	var decls []ast.Decl
+	for _, f := range files {
+		decls = append(decls, f.Decls...)
+	}
+	return decls
+}
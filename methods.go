@@ -0,0 +1,264 @@
+package structjson
+
+import (
+	"go/ast"
+	"go/token"
+	"sort"
+)
+
+// MethodDefinition is a method declared on a named type.
+type MethodDefinition struct {
+	Name     string   `json:"name"`
+	Exported bool     `json:"exported"`
+	Receiver string   `json:"receiver"` // name of the receiver type (e.g. "Person")
+	Pointer  bool     `json:"pointer"`  // declared with pointer receiver (e.g. func (p *Person) M())
+	Params   []*Param `json:"params"`
+	Results  []*Param `json:"results"`
+	Variadic bool     `json:"variadic"` // the last param is variadic (e.g. ...string)
+	Doc      string   `json:"doc,omitempty"`
+	Pos      *Pos     `json:"pos,omitempty"`
+}
+
+// MethodSetEntry is a method in the method set of a type, including the methods promoted through embedded fields.
+type MethodSetEntry struct {
+	Name    string `json:"name"`
+	Origin  string `json:"origin"`  // type declaring the method (e.g. "models.Person")
+	Package string `json:"package"` // full name of the module declaring the method
+	Depth   int    `json:"depth"`   // 0 if declared on the type itself
+	Pointer bool   `json:"pointer"` // declared with pointer receiver
+}
+
+// AddMethod adds the method declared by decl to r.Methods.
+// the method is attached to the definition of the receiver type by World.ComputeMethodSets.
+func (r *Result) AddMethod(decl *ast.FuncDecl) (*MethodDefinition, error) {
+	recv := decl.Recv.List[0].Type
+	_, pointer := recv.(*ast.StarExpr)
+	if paren, ok := recv.(*ast.ParenExpr); ok {
+		_, pointer = paren.X.(*ast.StarExpr)
+	}
+	// the packages referenced only in method signatures are not parsed.
+	needParse := make(map[string]bool, len(r.ImportsMap))
+	for name, im := range r.ImportsMap {
+		needParse[name] = im.NeedParse
+	}
+	defer func() {
+		for name, im := range r.ImportsMap {
+			im.NeedParse = needParse[name]
+		}
+	}()
+	item := &MethodDefinition{
+		Name:     decl.Name.Name,
+		Exported: token.IsExported(decl.Name.Name),
+		Receiver: findName(recv),
+		Pointer:  pointer,
+		Params:   findParams(r, decl.Type.Params),
+		Results:  findParams(r, decl.Type.Results),
+		Variadic: isVariadic(decl.Type),
+		Doc:      commentText(decl.Doc),
+		Pos:      r.findPos(decl.Pos(), decl.End()),
+	}
+	r.Methods = append(r.Methods, item)
+	return item, nil
+}
+
+// ComputeMethodSets attaches the methods collected in w to the definitions of their receiver types,
+// and computes MethodSet and PointerMethodSet of all structs and defined types in w.
+// methods promoted through embedded fields are included for structs (as go's selector rule),
+// also through the embedded structs excluded by the type visibility and the embedded defined types (e.g. type Status int).
+func (w *World) ComputeMethodSets() {
+	for _, m := range w.Modules {
+		for _, files := range []map[string]*Result{m.Files, m.hiddenFiles} {
+			for _, r := range files {
+				for _, def := range r.StructMap {
+					def.Methods = nil
+				}
+				for _, def := range r.hiddenStructMap {
+					def.Methods = nil
+				}
+				for _, def := range r.AliasMap {
+					def.Methods = nil
+				}
+			}
+		}
+	}
+	for _, m := range w.Modules {
+		for _, r := range m.Files {
+			for _, method := range r.Methods {
+				if _, def := m.LookupStruct(method.Receiver); def != nil {
+					def.Methods = append(def.Methods, method)
+				} else if _, def := m.LookupAlias(method.Receiver); def != nil {
+					def.Methods = append(def.Methods, method)
+				} else if _, def := m.lookupHiddenStruct(method.Receiver); def != nil {
+					// promoted through the embedded field
+					def.Methods = append(def.Methods, method)
+				}
+			}
+		}
+	}
+	for _, m := range w.Modules {
+		for _, r := range m.Files {
+			for _, def := range r.StructMap {
+				sort.Slice(def.Methods, func(i, j int) bool { return def.Methods[i].Name < def.Methods[j].Name })
+				def.MethodSet, def.PointerMethodSet = w.methodSets(&structRef{Module: m, Result: r, Def: def})
+			}
+			for _, def := range r.AliasMap {
+				sort.Slice(def.Methods, func(i, j int) bool { return def.Methods[i].Name < def.Methods[j].Name })
+				def.MethodSet, def.PointerMethodSet = nil, nil
+				for _, method := range def.Methods {
+					entry := &MethodSetEntry{Name: method.Name, Origin: m.Name + "." + def.Name, Package: m.FullName, Pointer: method.Pointer}
+					if !method.Pointer {
+						def.MethodSet = append(def.MethodSet, entry)
+					}
+					def.PointerMethodSet = append(def.PointerMethodSet, entry)
+				}
+			}
+		}
+	}
+}
+
+// methodSets returns the method sets of the struct type and of the pointer type of it.
+func (w *World) methodSets(root *structRef) (value []*MethodSetEntry, pointer []*MethodSetEntry) {
+	type item struct {
+		ref   *structRef
+		iface *InterfaceDefinition // embedded interface (ref.Def is nil)
+		alias *AliasDefinition     // embedded defined type other than struct and interface (ref.Def is nil)
+		iref  *namedRef            // of iface or alias
+		ptr   bool                 // embedded through pointer, pointer receiver methods are in the value method set
+	}
+	type candidate struct {
+		entry   *MethodSetEntry
+		inValue bool
+	}
+
+	seen := map[string]bool{} // names (of fields and methods) found at shallower depth
+	visited := map[*StructDefinition]bool{}
+	current := []item{{ref: root}}
+	for depth := 0; len(current) > 0; depth++ {
+		var next []item
+		found := map[string][]*candidate{}
+		var names []string
+		add := func(name string, c *candidate) {
+			if _, ok := found[name]; !ok {
+				names = append(names, name)
+			}
+			found[name] = append(found[name], c)
+		}
+		for _, it := range current {
+			if it.iface != nil {
+				for _, method := range w.interfaceMethods(it.iref, map[*InterfaceDefinition]bool{}) {
+					add(method.Name, &candidate{
+						entry:   &MethodSetEntry{Name: method.Name, Origin: it.iref.Module.Name + "." + it.iface.Name, Package: it.iref.Module.FullName, Depth: depth},
+						inValue: true,
+					})
+				}
+				continue
+			}
+			if it.alias != nil {
+				for _, method := range it.alias.Methods {
+					add(method.Name, &candidate{
+						entry:   &MethodSetEntry{Name: method.Name, Origin: it.iref.Module.Name + "." + it.alias.Name, Package: it.iref.Module.FullName, Depth: depth, Pointer: method.Pointer},
+						inValue: !method.Pointer || it.ptr,
+					})
+				}
+				continue
+			}
+			if visited[it.ref.Def] {
+				continue
+			}
+			visited[it.ref.Def] = true
+			for _, method := range it.ref.Def.Methods {
+				add(method.Name, &candidate{
+					entry:   &MethodSetEntry{Name: method.Name, Origin: it.ref.Module.Name + "." + it.ref.Def.Name, Package: it.ref.Module.FullName, Depth: depth, Pointer: method.Pointer},
+					inValue: !method.Pointer || it.ptr,
+				})
+			}
+			for _, sf := range sortedFields(it.ref.Def) {
+				add(sf.Name, nil) // a field hides the methods of the same name at deeper depth
+				if !sf.Embed {
+					continue
+				}
+				_, ptr := sf.Type.(*PointerType)
+				if embedded := w.resolveEmbedded(it.ref.Module, it.ref.Result, sf.Type); embedded != nil {
+					next = append(next, item{ref: embedded, ptr: it.ptr || ptr})
+				} else if ref, iface := w.resolveInterface(it.ref.Module, it.ref.Result, sf.Type); iface != nil {
+					next = append(next, item{iface: iface, iref: ref})
+				} else if ref, alias := w.resolveDefined(it.ref.Module, it.ref.Result, sf.Type); alias != nil {
+					next = append(next, item{alias: alias, iref: ref, ptr: it.ptr || ptr})
+				}
+			}
+		}
+		for _, name := range names {
+			if seen[name] {
+				continue // shadowed
+			}
+			seen[name] = true
+			candidates := found[name]
+			if len(candidates) != 1 || candidates[0] == nil {
+				continue // ambiguous, or a field
+			}
+			if candidates[0].inValue {
+				value = append(value, candidates[0].entry)
+			}
+			pointer = append(pointer, candidates[0].entry)
+		}
+		current = next
+	}
+	sort.Slice(value, func(i, j int) bool { return value[i].Name < value[j].Name })
+	sort.Slice(pointer, func(i, j int) bool { return pointer[i].Name < pointer[j].Name })
+	return value, pointer
+}
+
+// resolveInterface finds the interface definition of typ, referenced in r of m, following true aliases. returns nil if not found.
+func (w *World) resolveInterface(m *Module, r *Result, typ Type) (*namedRef, *InterfaceDefinition) {
+	ref := w.followAlias(w.lookupNamed(m, r, typ))
+	if ref == nil {
+		return nil, nil
+	}
+	_, def := ref.Module.LookupInterface(ref.Name)
+	if def == nil {
+		return nil, nil
+	}
+	return ref, def
+}
+
+// resolveDefined finds the alias definition of the defined type typ (e.g. type Status int), referenced in r of m, following true aliases.
+// typ may be a pointer (an embedded *Status). returns nil if not found.
+func (w *World) resolveDefined(m *Module, r *Result, typ Type) (*namedRef, *AliasDefinition) {
+	if t, ok := typ.(*PointerType); ok {
+		typ = t.Value
+	}
+	ref := w.followAlias(w.lookupNamed(m, r, typ))
+	if ref == nil {
+		return nil, nil
+	}
+	_, def := ref.Module.LookupAlias(ref.Name)
+	if def == nil {
+		return nil, nil
+	}
+	return ref, def
+}
+
+// interfaceMethods returns the methods of the interface referred by ref, including the methods of embedded interfaces.
+func (w *World) interfaceMethods(ref *namedRef, visited map[*InterfaceDefinition]bool) []*Method {
+	r, def := ref.Module.LookupInterface(ref.Name)
+	if def == nil || visited[def] {
+		return nil
+	}
+	visited[def] = true
+	methods := append([]*Method(nil), def.Methods...)
+	for _, embed := range def.Embeds {
+		if ref, _ := w.resolveInterface(ref.Module, r, embed); ref != nil {
+			methods = append(methods, w.interfaceMethods(ref, visited)...)
+		}
+	}
+	// the same method may be embedded through multiple interfaces
+	seen := map[string]bool{}
+	uniq := methods[:0]
+	for _, method := range methods {
+		if !seen[method.Name] {
+			seen[method.Name] = true
+			uniq = append(uniq, method)
+		}
+	}
+	return uniq
+}
@@ -0,0 +1,47 @@
+package structjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMethodSets(t *testing.T) {
+	_, m := loadTestdata(t, "methods")
+	names := func(entries []*MethodSetEntry) []string {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name+":"+e.Origin)
+		}
+		return names
+	}
+	cases := []struct {
+		msg         string
+		name        string
+		wantValue   []string
+		wantPointer []string
+	}{
+		{
+			msg:         "through unexported struct and defined type",
+			name:        "X",
+			wantValue:   []string{"MarshalJSON:methods.base", "String:methods.Status"},
+			wantPointer: []string{"MarshalJSON:methods.base", "Reset:methods.base", "Set:methods.Status", "String:methods.Status"},
+		},
+		{
+			msg:         "through pointer to defined type",
+			name:        "Y",
+			wantValue:   []string{"Set:methods.Status", "String:methods.Status"},
+			wantPointer: []string{"Set:methods.Status", "String:methods.Status"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.msg, func(t *testing.T) {
+			_, def := m.LookupStruct(c.name)
+			if got := names(def.MethodSet); !reflect.DeepEqual(got, c.wantValue) {
+				t.Errorf("method set of %s\nwant %q\n got %q", c.name, c.wantValue, got)
+			}
+			if got := names(def.PointerMethodSet); !reflect.DeepEqual(got, c.wantPointer) {
+				t.Errorf("pointer method set of %s\nwant %q\n got %q", c.name, c.wantPointer, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package structjson
+
+import (
+	"go/token"
+	"path/filepath"
+)
+
+// Pos is a position in source. End has no Filename.
+type Pos struct {
+	Filename string `json:"filename,omitempty"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Offset   int    `json:"offset"`
+	End      *Pos   `json:"end,omitempty"`
+}
+
+// findPos returns the position of the node between start and end. if FileSet is not available, returns nil.
+func (r *Result) findPos(start, end token.Pos) *Pos {
+	if r.fset == nil || !start.IsValid() {
+		return nil
+	}
+	p := r.fset.Position(start)
+	pos := &Pos{Filename: p.Filename, Line: p.Line, Column: p.Column, Offset: p.Offset}
+	if r.root != "" {
+		if rel, err := filepath.Rel(r.root, p.Filename); err == nil {
+			pos.Filename = rel
+		}
+	}
+	if end.IsValid() {
+		e := r.fset.Position(end)
+		pos.End = &Pos{Line: e.Line, Column: e.Column, Offset: e.Offset}
+	}
+	return pos
+}
@@ -0,0 +1,200 @@
+package structjson
+
+import "sort"
+
+// PromotedField is a field selectable from a struct, including the fields promoted through embedded structs.
+type PromotedField struct {
+	Name    string `json:"name"`
+	Origin  string `json:"origin"`  // struct declaring the field, with the full package name (e.g. "github.com/podhmo/go-structjson/examples/models.Person")
+	Package string `json:"package"` // full name of the module declaring the field
+	Depth   int    `json:"depth"`   // 0 if declared in the struct itself
+	Index   []int  `json:"index"`   // index sequence through embedded structs
+	Type    Type   `json:"type"`
+	Embed   bool   `json:"embed"`
+}
+
+// structRef is a struct definition with the place where it is defined.
+type structRef struct {
+	Module *Module
+	Result *Result
+	Def    *StructDefinition
+}
+
+// LookupModule returns the module whose FullName is fullname.
+func (w *World) LookupModule(fullname string) *Module {
+	if m, ok := w.Modules[fullname]; ok {
+		return m
+	}
+	// the output of older versions is keyed by the package name.
+	for _, m := range w.Modules {
+		if m.FullName == fullname {
+			return m
+		}
+	}
+	return nil
+}
+
+// LookupStruct returns the struct definition named name, and the file defining it.
+func (m *Module) LookupStruct(name string) (*Result, *StructDefinition) {
+	for _, r := range m.Files {
+		if def, ok := r.StructMap[name]; ok {
+			return r, def
+		}
+	}
+	return nil, nil
+}
+
+// lookupHiddenStruct returns the struct definition named name, excluded by the type visibility.
+func (m *Module) lookupHiddenStruct(name string) (*Result, *StructDefinition) {
+	for _, files := range []map[string]*Result{m.Files, m.hiddenFiles} {
+		for _, r := range files {
+			if def, ok := r.hiddenStructMap[name]; ok {
+				return r, def
+			}
+		}
+	}
+	return nil, nil
+}
+
+// declares reports whether the type named name is declared in m, including the types excluded by the type visibility.
+func (m *Module) declares(name string) bool {
+	for _, files := range []map[string]*Result{m.Files, m.hiddenFiles} {
+		for _, r := range files {
+			if r.StructMap[name] != nil || r.AliasMap[name] != nil || r.InterfaceMap[name] != nil || r.hiddenTypes[name] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// resolveEmbedded finds the struct definition of the embedded field typ, like resolveStruct.
+// the struct excluded by the type visibility is also found, because its fields are promoted anyway.
+func (w *World) resolveEmbedded(m *Module, r *Result, typ Type) *structRef {
+	if ref := w.resolveStruct(m, r, typ); ref != nil {
+		return ref
+	}
+	if t, ok := typ.(*PointerType); ok {
+		typ = t.Value
+	}
+	ref := w.followAlias(w.lookupNamed(m, r, typ))
+	if ref == nil {
+		return nil
+	}
+	if r, def := ref.Module.lookupHiddenStruct(ref.Name); def != nil {
+		return &structRef{Module: ref.Module, Result: r, Def: def}
+	}
+	return nil
+}
+
+// resolveStruct finds the struct definition of typ, referenced in r of m, following true aliases. returns nil if not found.
+func (w *World) resolveStruct(m *Module, r *Result, typ Type) *structRef {
+	if t, ok := typ.(*PointerType); ok {
+		return w.resolveStruct(m, r, t.Value)
+	}
+	ref := w.followAlias(w.lookupNamed(m, r, typ))
+	if ref == nil {
+		return nil
+	}
+	if r, def := ref.Module.LookupStruct(ref.Name); def != nil {
+		return &structRef{Module: ref.Module, Result: r, Def: def}
+	}
+	return nil
+}
+
+// sortedFields returns the fields of def in declaration order, including the embedded fields excluded by the field visibility.
+func sortedFields(def *StructDefinition) []*Field {
+	fields := make([]*Field, 0, len(def.Fields)+len(def.hiddenEmbeds))
+	for _, f := range def.Fields {
+		fields = append(fields, f)
+	}
+	fields = append(fields, def.hiddenEmbeds...)
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Index < fields[j].Index })
+	return fields
+}
+
+// hides reports whether f is the embedded field of def excluded by the field visibility.
+func (def *StructDefinition) hides(f *Field) bool {
+	for _, h := range def.hiddenEmbeds {
+		if h == f {
+			return true
+		}
+	}
+	return false
+}
+
+// ComputePromotedFields computes Promoted of all structs in w, following embedding across files and modules in w.
+// a field shadowed by a shallower one, or ambiguous at the same depth, is excluded (as go's selector rule).
+// the embedded field excluded by the field visibility is also excluded, but the fields promoted through it are not.
+func (w *World) ComputePromotedFields() {
+	for _, m := range w.Modules {
+		for _, r := range m.Files {
+			for _, def := range r.StructMap {
+				def.Promoted = w.promotedFields(&structRef{Module: m, Result: r, Def: def})
+			}
+		}
+	}
+}
+
+func (w *World) promotedFields(root *structRef) []*PromotedField {
+	type item struct {
+		ref   *structRef
+		index []int
+	}
+
+	var fields []*PromotedField
+	seen := map[string]bool{}               // field names found at shallower depth
+	visited := map[*StructDefinition]bool{} // structs explored at shallower depth
+	current := []item{{ref: root}}
+	for depth := 0; len(current) > 0; depth++ {
+		var next []item
+		found := map[string][]*PromotedField{}
+		var names []string
+		for _, it := range current {
+			if visited[it.ref.Def] {
+				continue
+			}
+			for _, sf := range sortedFields(it.ref.Def) {
+				index := make([]int, len(it.index)+1)
+				copy(index, it.index)
+				index[len(it.index)] = sf.Index
+
+				if sf.Embed {
+					if embedded := w.resolveEmbedded(it.ref.Module, it.ref.Result, sf.Type); embedded != nil {
+						next = append(next, item{ref: embedded, index: index})
+					}
+				}
+				if it.ref.Def.hides(sf) {
+					continue
+				}
+				if _, ok := found[sf.Name]; !ok {
+					names = append(names, sf.Name)
+				}
+				found[sf.Name] = append(found[sf.Name], &PromotedField{
+					Name:    sf.Name,
+					Origin:  it.ref.Module.FullName + "." + it.ref.Def.Name,
+					Package: it.ref.Module.FullName,
+					Depth:   depth,
+					Index:   index,
+					Type:    sf.Type,
+					Embed:   sf.Embed,
+				})
+			}
+		}
+		for _, it := range current {
+			visited[it.ref.Def] = true
+		}
+		for _, name := range names {
+			if seen[name] {
+				continue // shadowed
+			}
+			seen[name] = true
+			if candidates := found[name]; len(candidates) == 1 {
+				fields = append(fields, candidates[0])
+			}
+		}
+		current = next
+	}
+	sort.Slice(fields, func(i, j int) bool { return compareIndex(fields[i].Index, fields[j].Index) < 0 })
+	return fields
+}
@@ -0,0 +1,53 @@
+package structjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPromotedFields(t *testing.T) {
+	w, m := loadTestdata(t, "wirefields")
+	cases := []struct {
+		msg  string
+		name string
+		want []string
+	}{
+		{msg: "through unexported embed", name: "Item", want: []string{"base", "ID", "Kind", "Name"}},
+		{msg: "ambiguous at same depth", name: "Ambiguous", want: []string{"X1", "X2", "Own"}},
+		{msg: "shallower wins", name: "Shadowed", want: []string{"X1", "X"}},
+		{msg: "through pointer embed", name: "Deep", want: []string{"Admin", "Base", "ID", "Audit", "UpdatedAt", "Group", "Level", "Password", "Dash", "Name", "NAME", "secret"}}, // CreatedAt and Note are ambiguous
+	}
+	for _, c := range cases {
+		t.Run(c.msg, func(t *testing.T) {
+			_, def := m.LookupStruct(c.name)
+			var got []string
+			for _, f := range def.Promoted {
+				got = append(got, f.Name)
+				if _, _, origin := w.LookupOrigin(f.Origin); origin == nil {
+					t.Errorf("%s.%s: origin %s is not found", c.name, f.Name, f.Origin)
+				}
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("promoted fields of %s\nwant %q\n got %q", c.name, c.want, got)
+			}
+		})
+	}
+}
+
+func TestHiddenEmbed(t *testing.T) {
+	_, m := loadTestdata(t, "wirefields", func(c *Config) { c.FieldVisibility = VisibilityExported })
+	_, def := m.LookupStruct("Item")
+	if _, ok := def.Fields["base"]; ok {
+		t.Errorf("unexported embed base is in the fields of Item")
+	}
+	var promoted []string
+	for _, f := range def.Promoted {
+		promoted = append(promoted, f.Name)
+	}
+	if want := []string{"ID", "Kind", "Name"}; !reflect.DeepEqual(promoted, want) {
+		t.Errorf("promoted fields of Item\nwant %q\n got %q", want, promoted)
+	}
+	if want, got := []string{"id:wirefields.base", "kind:wirefields.base", "name:wirefields.Item"}, wireNames(m, "Item"); !reflect.DeepEqual(got, want) {
+		t.Errorf("wire fields of Item\nwant %q\n got %q", want, got)
+	}
+}
@@ -0,0 +1,48 @@
+package structjson
+
+import "go/types"
+
+// Ref is a reference to a named type.
+type Ref struct {
+	Package string  // full name of the package defining the type (e.g. "time")
+	Name    string  // name of the type (e.g. "Time")
+	Module  *Module // module of the package, nil if not collected in the world
+}
+
+// String returns the qualified name with the full package name (e.g. "time.Time").
+func (ref *Ref) String() string {
+	return ref.Package + "." + ref.Name
+}
+
+// ResolveRef returns the reference of typ (a named type, possibly instantiated), referenced in r of m.
+// returns nil if typ is not a reference to a declared type (e.g. builtin types, type literals).
+func (w *World) ResolveRef(m *Module, r *Result, typ Type) *Ref {
+	switch t := typ.(type) {
+	case *InstantiationType:
+		return w.ResolveRef(m, r, t.Value)
+	case *PrimitiveType:
+		if t.TypeInfo != nil {
+			if t.TypeInfo.Builtin {
+				return nil
+			}
+			if t.TypeInfo.Package != "" && t.TypeInfo.Package != m.FullName {
+				return &Ref{Package: t.TypeInfo.Package, Name: t.Value, Module: w.LookupModule(t.TypeInfo.Package)}
+			}
+		} else if _, ok := types.Universe.Lookup(t.Value).(*types.TypeName); ok {
+			return nil
+		}
+		return &Ref{Package: m.FullName, Name: t.Value, Module: m}
+	case *SelectorType:
+		fullname := ""
+		if t.TypeInfo != nil {
+			fullname = t.TypeInfo.Package
+		} else if im, ok := r.ImportsMap[t.Prefix]; ok {
+			fullname = im.FullName
+		}
+		if fullname == "" {
+			return nil
+		}
+		return &Ref{Package: fullname, Name: t.Value, Module: w.LookupModule(fullname)}
+	}
+	return nil
+}
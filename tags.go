@@ -0,0 +1,89 @@
+package structjson
+
+import (
+	"fmt"
+	"go/ast"
+	"strconv"
+	"strings"
+)
+
+// Tag is a key:"value" pair of struct tag.
+type Tag struct {
+	Key   string
+	Value string
+}
+
+// ParseStructTag parses tag (unquoted) with the grammar of reflect.StructTag.
+// if tag is malformed, returns the pairs found before the malformed part, and an error.
+func ParseStructTag(tag string) ([]Tag, error) {
+	var pairs []Tag
+	for tag != "" {
+		// skip leading space
+		i := 0
+		for i < len(tag) && tag[i] == ' ' {
+			i++
+		}
+		tag = tag[i:]
+		if tag == "" {
+			break
+		}
+
+		// scan to colon. a space, a quote or a control character is a syntax error.
+		i = 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			return pairs, fmt.Errorf("bad syntax for struct tag pair %q", tag)
+		}
+		key := tag[:i]
+		tag = tag[i+1:]
+
+		// scan quoted string to find value
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			return pairs, fmt.Errorf("bad syntax for struct tag value of %s", key)
+		}
+		qvalue := tag[:i+1]
+		tag = tag[i+1:]
+
+		value, err := strconv.Unquote(qvalue)
+		if err != nil {
+			return pairs, fmt.Errorf("bad syntax for struct tag value of %s: %s", key, err)
+		}
+		pairs = append(pairs, Tag{Key: key, Value: value})
+	}
+	return pairs, nil
+}
+
+// parseTags returns the raw tag, the raw values and the comma-split options per key.
+func (r *Result) parseTags(node *ast.Field, name string, rawTag string) (string, map[string]string, map[string][]string) {
+	tags := map[string][]string{}
+	if rawTag == "" {
+		return "", nil, tags
+	}
+	unquoted, err := strconv.Unquote(rawTag)
+	if err != nil {
+		r.addDiagnostic(SeverityWarning, node, fmt.Sprintf("%s: struct tag %s is not quoted correctly", name, rawTag))
+		return rawTag, nil, tags
+	}
+	pairs, err := ParseStructTag(unquoted)
+	if err != nil {
+		r.addDiagnostic(SeverityWarning, node, fmt.Sprintf("%s: %s", name, err))
+	}
+	values := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		if _, exists := values[pair.Key]; exists {
+			continue // the first one is used, same as reflect.StructTag.Lookup
+		}
+		values[pair.Key] = pair.Value
+		tags[pair.Key] = strings.Split(pair.Value, ",")
+	}
+	return unquoted, values, tags
+}
@@ -0,0 +1,39 @@
+package structjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseStructTag(t *testing.T) {
+	cases := []struct {
+		msg     string
+		tag     string
+		want    []Tag
+		wantErr bool
+	}{
+		{msg: "empty", tag: ""},
+		{msg: "one", tag: `json:"name"`, want: []Tag{{Key: "json", Value: "name"}}},
+		{msg: "options", tag: `json:"name,omitempty,string"`, want: []Tag{{Key: "json", Value: "name,omitempty,string"}}},
+		{msg: "many", tag: `json:"name"  protobuf:"3" graphql:"-"`, want: []Tag{{Key: "json", Value: "name"}, {Key: "protobuf", Value: "3"}, {Key: "graphql", Value: "-"}}},
+		{msg: "duplicated key is kept", tag: `json:"a" json:"b"`, want: []Tag{{Key: "json", Value: "a"}, {Key: "json", Value: "b"}}},
+		{msg: "escaped quote", tag: `doc:"say \"hi\""`, want: []Tag{{Key: "doc", Value: `say "hi"`}}},
+		{msg: "empty value", tag: `json:""`, want: []Tag{{Key: "json", Value: ""}}},
+		{msg: "no colon", tag: `json`, wantErr: true},
+		{msg: "space before colon", tag: `json :"name"`, wantErr: true},
+		{msg: "unquoted value", tag: `json:name`, wantErr: true},
+		{msg: "unterminated", tag: `json:"name`, wantErr: true},
+		{msg: "pairs before malformed part", tag: `json:"name" bad`, want: []Tag{{Key: "json", Value: "name"}}, wantErr: true},
+	}
+	for _, c := range cases {
+		t.Run(c.msg, func(t *testing.T) {
+			got, err := ParseStructTag(c.tag)
+			if (err != nil) != c.wantErr {
+				t.Fatalf("ParseStructTag(%q): unexpected error %v", c.tag, err)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("ParseStructTag(%q)\nwant %#v\n got %#v", c.tag, c.want, got)
+			}
+		})
+	}
+}
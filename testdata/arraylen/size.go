@@ -0,0 +1,5 @@
+package arraylen
+
+const Size = 16
+
+const Double = Size * 2
@@ -0,0 +1,8 @@
+package arraylen
+
+type Digest [Size]byte
+
+type Block struct {
+	Data [Double]byte
+	Sums [2][Size]byte
+}
@@ -0,0 +1,29 @@
+package consts
+
+const (
+	FlagA Flags = 1 << iota
+	FlagB
+	FlagC
+)
+
+const (
+	AllFlags   = ^Flags(0)
+	AllMask    = ^Mask(0)
+	AllUint32  = ^uint32(0)
+	NotLevel   = ^Level(1)
+	Untyped    = ^1
+	Shifted    = Level(1) << 10
+	Divided    = Level(7) / 2
+	Negative   = -Level(3)
+	Name       = "a" + "b"
+	NameLength = Level(len(Name))
+)
+
+const (
+	Low Level = iota * 10
+	Mid
+	High
+	skipped
+	_
+	Top = High + 100
+)
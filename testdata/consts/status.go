@@ -0,0 +1,9 @@
+package consts
+
+const StatusDraft Status = "draft"
+
+const (
+	PriorityLow Priority = iota
+	PriorityMid
+	PriorityHigh
+)
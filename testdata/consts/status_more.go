@@ -0,0 +1,13 @@
+package consts
+
+const (
+	StatusActive   Status  = "active"
+	StatusInactive Status  = "inactive"
+	Max            int     = 10
+	Orphan         Unknown = 1
+)
+
+// PriorityUrgent refers to the const declared in status.go.
+const PriorityUrgent Priority = PriorityHigh + 1
+
+const Hidden hidden = 1
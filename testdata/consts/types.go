@@ -0,0 +1,13 @@
+package consts
+
+type Flags uint8
+type Mask Flags
+type Level int
+
+// Status :
+type Status string
+
+// Priority :
+type Priority int
+
+type hidden int
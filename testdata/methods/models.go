@@ -0,0 +1,24 @@
+package methods
+
+type base struct {
+	ID int
+}
+
+func (base) MarshalJSON() ([]byte, error) { return nil, nil }
+
+func (*base) Reset() {}
+
+type Status int
+
+func (Status) String() string { return "" }
+
+func (*Status) Set(s string) error { return nil }
+
+type X struct {
+	base
+	Status
+}
+
+type Y struct {
+	*Status
+}
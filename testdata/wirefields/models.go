@@ -0,0 +1,90 @@
+package wirefields
+
+type Base struct {
+	ID        string `json:"id"`
+	CreatedAt string `json:"createdAt,omitempty"`
+	Note      string `json:"note"`
+}
+
+type Audit struct {
+	CreatedAt string `json:"createdAt"`
+	UpdatedAt string `json:"updatedAt"`
+	Note      string `json:"note"`
+}
+
+type Group struct {
+	Name string `json:"name"`
+}
+
+type Admin struct {
+	*Base
+	Audit
+	Group    `json:"group"`
+	Level    int    `json:"level,string"`
+	Password string `json:"-"`
+	Dash     string `json:"-,"`
+	Name     string
+	NAME     string `json:"name"`
+	secret   string
+}
+
+type Deep struct {
+	Admin
+}
+
+type base struct {
+	ID   string `json:"id"`
+	Kind string `json:"kind"`
+}
+
+type Item struct {
+	base
+	Name string `json:"name"`
+}
+
+type X1 struct{ X string }
+type X2 struct{ X string }
+type XT struct {
+	Y string `json:"X"`
+}
+
+type Ambiguous struct {
+	X1
+	X2
+	Own string
+}
+
+type Shadowed struct {
+	X1
+	X int
+}
+
+type TagWins struct {
+	X1
+	XT
+}
+
+type status int
+
+type Ignored struct {
+	status
+	error
+	Name string
+}
+
+type Missing struct {
+	missing
+	Name string
+}
+
+type Level int
+type Names []string
+type Quoted struct {
+	Count int      `json:",string"`
+	Ptr   *int     `json:",string"`
+	Level Level    `json:",string"`
+	Tags  []string `json:",string"`
+	Names Names    `json:",string"`
+	Base  Base     `json:",string"`
+	PP    **int    `json:",string"`
+}
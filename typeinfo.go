@@ -0,0 +1,72 @@
+package structjson
+
+import (
+	"go/ast"
+	"go/types"
+)
+
+// findTypeInfo returns the type-checked information of expr (package, name, underlying, builtin).
+// if the result is not type-checked, returns nil.
+func (r *Result) findTypeInfo(expr ast.Expr) *TypeInfo {
+	if r.info == nil {
+		return nil
+	}
+	typ := r.info.TypeOf(expr)
+	if typ == nil {
+		return nil
+	}
+	info := &TypeInfo{}
+	switch t := typ.(type) {
+	case *types.Basic:
+		info.Builtin = true
+		info.Name = t.Name()
+	case interface{ Obj() *types.TypeName }: // *types.Named, *types.Alias, *types.TypeParam
+		obj := t.Obj()
+		info.Builtin = obj.Pkg() == nil
+		info.Name = obj.Name()
+		if obj.Pkg() != nil {
+			info.Package = obj.Pkg().Path()
+		}
+	}
+	info.Underlying = types.TypeString(types.Unalias(typ).Underlying(), nil)
+	return info
+}
+
+// findUnderlyingKind returns the kind of the underlying type of the type declared by spec (e.g. "func", "struct").
+// if the result is not type-checked, returns "".
+func (r *Result) findUnderlyingKind(spec *ast.TypeSpec) string {
+	if r.info == nil {
+		return ""
+	}
+	obj, ok := r.info.Defs[spec.Name].(*types.TypeName)
+	if !ok {
+		return ""
+	}
+	return underlyingKind(obj.Type())
+}
+
+// underlyingKind returns the kind of typ's underlying type, in the same names as Type.Kind().
+func underlyingKind(typ types.Type) string {
+	switch typ.Underlying().(type) {
+	case *types.Basic:
+		return "primitive"
+	case *types.Struct:
+		return "struct"
+	case *types.Interface:
+		return "interface"
+	case *types.Map:
+		return "map"
+	case *types.Slice:
+		return "slice"
+	case *types.Array:
+		return "array"
+	case *types.Pointer:
+		return "pointer"
+	case *types.Signature:
+		return "func"
+	case *types.Chan:
+		return "channel"
+	default:
+		return ""
+	}
+}
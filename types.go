@@ -0,0 +1,585 @@
+package structjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+)
+
+// Type is a type expression found in go source. the JSON representation is an object with a "kind" key.
+type Type interface {
+	Kind() string
+}
+
+// TypeInfo is the type-checked information of a type reference (only in typed mode).
+type TypeInfo struct {
+	Package    string `json:"package,omitempty"`
+	Name       string `json:"name,omitempty"`
+	Underlying string `json:"underlying,omitempty"`
+	Builtin    bool   `json:"builtin"`
+}
+
+// PrimitiveType is a type referenced by an identifier (e.g. string, Person).
+type PrimitiveType struct {
+	Value string `json:"value"`
+	*TypeInfo
+}
+
+// ArrayType is a fixed-size array type (e.g. [16]byte, [Size]byte).
+type ArrayType struct {
+	Value   Type   `json:"value"`
+	Len     int64  `json:"len"`               // evaluated length, -1 if not evaluated
+	LenExpr string `json:"lenexpr,omitempty"` // source representation of the length, if not a literal (e.g. "Size")
+}
+
+// SliceType is a slice type.
+type SliceType struct {
+	Value Type `json:"value"`
+}
+
+// MapType is a map type.
+type MapType struct {
+	Key   Type `json:"key"`
+	Value Type `json:"value"`
+}
+
+// StructType is an anonymous struct type.
+type StructType struct {
+	Fields []*Field `json:"fields"` // in declaration order
+}
+
+// InterfaceType is an anonymous interface type.
+type InterfaceType struct {
+	Methods []Type `json:"methods"`
+}
+
+// PointerType is a pointer type.
+type PointerType struct {
+	Value Type `json:"value"`
+}
+
+// SelectorType is a type referenced through an imported package (e.g. bson.ObjectId).
+type SelectorType struct {
+	Prefix string `json:"prefix"`
+	Value  string `json:"value"`
+	*TypeInfo
+}
+
+// FuncType is a func type.
+type FuncType struct {
+	Params   []*Param `json:"params"`
+	Results  []*Param `json:"results"`
+	Variadic bool     `json:"variadic"` // the last param is variadic (e.g. ...string)
+}
+
+// ChanType is a channel type.
+type ChanType struct {
+	Value Type    `json:"value"`
+	Dir   ChanDir `json:"dir"`
+}
+
+// ChanDir is the direction of a channel type.
+type ChanDir string
+
+const (
+	ChanBoth ChanDir = "both" // chan T
+	ChanSend ChanDir = "send" // chan<- T
+	ChanRecv ChanDir = "recv" // <-chan T
+)
+
+func chanDir(dir ast.ChanDir) ChanDir {
+	switch dir {
+	case ast.SEND:
+		return ChanSend
+	case ast.RECV:
+		return ChanRecv
+	default:
+		return ChanBoth
+	}
+}
+
+// UnmarshalJSON decodes d, also accepting the legacy form (the integer of ast.ChanDir).
+func (d *ChanDir) UnmarshalJSON(b []byte) error {
+	var n ast.ChanDir
+	if err := json.Unmarshal(b, &n); err == nil {
+		*d = chanDir(n)
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	*d = ChanDir(s)
+	return nil
+}
+
+// EllipsisType is the type of a variadic parameter (e.g. ...string).
+type EllipsisType struct {
+	Value Type `json:"value"`
+}
+
+// InstantiationType is an instantiated generic type (e.g. Page[T], Pair[string, int]).
+type InstantiationType struct {
+	Value Type   `json:"value"`
+	Args  []Type `json:"args"`
+}
+
+// UnionType is a union of type terms in a constraint (e.g. int | string).
+type UnionType struct {
+	Terms []Type `json:"terms"`
+}
+
+// ApproximationType is a type term with the underlying type in a constraint (e.g. ~int).
+type ApproximationType struct {
+	Value Type `json:"value"`
+}
+
+// UnknownType is a type expression not supported. Value is the source representation.
+type UnknownType struct {
+	Value string `json:"value"`
+}
+
+func (t *PrimitiveType) Kind() string     { return "primitive" }
+func (t *ArrayType) Kind() string         { return "array" }
+func (t *SliceType) Kind() string         { return "slice" }
+func (t *MapType) Kind() string           { return "map" }
+func (t *StructType) Kind() string        { return "struct" }
+func (t *InterfaceType) Kind() string     { return "interface" }
+func (t *PointerType) Kind() string       { return "pointer" }
+func (t *SelectorType) Kind() string      { return "selector" }
+func (t *FuncType) Kind() string          { return "func" }
+func (t *ChanType) Kind() string          { return "channel" }
+func (t *EllipsisType) Kind() string      { return "ellipsis" }
+func (t *InstantiationType) Kind() string { return "instantiation" }
+func (t *UnionType) Kind() string         { return "union" }
+func (t *ApproximationType) Kind() string { return "approximation" }
+func (t *UnknownType) Kind() string       { return "unknown" }
+
+// marshalType encodes v as a JSON object, with "kind" as the first key.
+func marshalType(kind string, v interface{}) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `{"kind":%q`, kind)
+	if body := bytes.TrimSpace(b[1 : len(b)-1]); len(body) > 0 {
+		buf.WriteByte(',')
+		buf.Write(body)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (t *PrimitiveType) MarshalJSON() ([]byte, error) {
+	type plain PrimitiveType
+	return marshalType(t.Kind(), (*plain)(t))
+}
+func (t *ArrayType) MarshalJSON() ([]byte, error) {
+	type plain ArrayType
+	return marshalType(t.Kind(), (*plain)(t))
+}
+func (t *SliceType) MarshalJSON() ([]byte, error) {
+	type plain SliceType
+	return marshalType(t.Kind(), (*plain)(t))
+}
+func (t *MapType) MarshalJSON() ([]byte, error) {
+	type plain MapType
+	return marshalType(t.Kind(), (*plain)(t))
+}
+func (t *StructType) MarshalJSON() ([]byte, error) {
+	type plain StructType
+	return marshalType(t.Kind(), (*plain)(t))
+}
+func (t *InterfaceType) MarshalJSON() ([]byte, error) {
+	type plain InterfaceType
+	return marshalType(t.Kind(), (*plain)(t))
+}
+func (t *PointerType) MarshalJSON() ([]byte, error) {
+	type plain PointerType
+	return marshalType(t.Kind(), (*plain)(t))
+}
+func (t *SelectorType) MarshalJSON() ([]byte, error) {
+	type plain SelectorType
+	return marshalType(t.Kind(), (*plain)(t))
+}
+func (t *FuncType) MarshalJSON() ([]byte, error) {
+	type plain FuncType
+	return marshalType(t.Kind(), (*plain)(t))
+}
+func (t *ChanType) MarshalJSON() ([]byte, error) {
+	type plain ChanType
+	return marshalType(t.Kind(), (*plain)(t))
+}
+func (t *EllipsisType) MarshalJSON() ([]byte, error) {
+	type plain EllipsisType
+	return marshalType(t.Kind(), (*plain)(t))
+}
+func (t *InstantiationType) MarshalJSON() ([]byte, error) {
+	type plain InstantiationType
+	return marshalType(t.Kind(), (*plain)(t))
+}
+func (t *UnionType) MarshalJSON() ([]byte, error) {
+	type plain UnionType
+	return marshalType(t.Kind(), (*plain)(t))
+}
+func (t *ApproximationType) MarshalJSON() ([]byte, error) {
+	type plain ApproximationType
+	return marshalType(t.Kind(), (*plain)(t))
+}
+func (t *UnknownType) MarshalJSON() ([]byte, error) {
+	type plain UnknownType
+	return marshalType(t.Kind(), (*plain)(t))
+}
+
+// UnmarshalType decodes a JSON representation of Type (the inverse of json.Marshal).
+// the legacy form (the output of older versions) is also accepted. an array without "len" is decoded as a slice,
+// the older versions did not distinguish them.
+func UnmarshalType(data []byte) (Type, error) {
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil, nil
+	}
+	var head struct {
+		Kind string          `json:"kind"`
+		Len  json.RawMessage `json:"len"`
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return nil, err
+	}
+	var t Type
+	switch head.Kind {
+	case "primitive":
+		t = &PrimitiveType{}
+	case "array":
+		if head.Len == nil {
+			t = &SliceType{}
+		} else {
+			t = &ArrayType{}
+		}
+	case "slice":
+		t = &SliceType{}
+	case "map":
+		t = &MapType{}
+	case "struct":
+		t = &StructType{}
+	case "interface":
+		t = &InterfaceType{}
+	case "pointer":
+		t = &PointerType{}
+	case "selector":
+		t = &SelectorType{}
+	case "func":
+		t = &FuncType{}
+	case "channel":
+		t = &ChanType{}
+	case "ellipsis":
+		t = &EllipsisType{}
+	case "instantiation":
+		t = &InstantiationType{}
+	case "union":
+		t = &UnionType{}
+	case "approximation":
+		t = &ApproximationType{}
+	case "unknown":
+		t = &UnknownType{}
+	default:
+		return nil, fmt.Errorf("unknown kind %q", head.Kind)
+	}
+	if err := json.Unmarshal(data, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// legacyType decodes data as a Type, if data is the legacy form of a field or a param (a type without the name).
+func legacyType(data []byte) (Type, bool, error) {
+	var head struct {
+		Kind *string `json:"kind"`
+	}
+	if err := json.Unmarshal(data, &head); err != nil || head.Kind == nil {
+		return nil, false, err
+	}
+	t, err := UnmarshalType(data)
+	return t, true, err
+}
+
+func unmarshalTypes(data []byte) ([]Type, error) {
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil, nil
+	}
+	var raws []json.RawMessage
+	if err := json.Unmarshal(data, &raws); err != nil {
+		return nil, err
+	}
+	types := make([]Type, len(raws))
+	for i, raw := range raws {
+		t, err := UnmarshalType(raw)
+		if err != nil {
+			return nil, err
+		}
+		types[i] = t
+	}
+	return types, nil
+}
+
+func (t *ArrayType) UnmarshalJSON(b []byte) error {
+	raw := struct {
+		Value   json.RawMessage `json:"value"`
+		Len     int64           `json:"len"`
+		LenExpr string          `json:"lenexpr"`
+	}{Len: -1} // the legacy form has no length
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	value, err := UnmarshalType(raw.Value)
+	t.Value, t.Len, t.LenExpr = value, raw.Len, raw.LenExpr
+	return err
+}
+
+func (t *SliceType) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	value, err := UnmarshalType(raw.Value)
+	t.Value = value
+	return err
+}
+
+func (t *MapType) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Key   json.RawMessage `json:"key"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	key, err := UnmarshalType(raw.Key)
+	if err != nil {
+		return err
+	}
+	value, err := UnmarshalType(raw.Value)
+	t.Key, t.Value = key, value
+	return err
+}
+
+func (t *FuncType) UnmarshalJSON(b []byte) error {
+	type plain FuncType
+	raw := struct {
+		*plain
+		Args []*Param `json:"args"` // legacy form of params
+	}{plain: (*plain)(t)}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if raw.Args != nil && t.Params == nil {
+		t.Params = raw.Args
+		if n := len(t.Params); n > 0 {
+			_, t.Variadic = t.Params[n-1].Type.(*EllipsisType)
+		}
+	}
+	return nil
+}
+
+func (t *InterfaceType) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Methods json.RawMessage `json:"methods"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	methods, err := unmarshalTypes(raw.Methods)
+	t.Methods = methods
+	return err
+}
+
+func (t *PointerType) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	value, err := UnmarshalType(raw.Value)
+	t.Value = value
+	return err
+}
+
+func (t *ChanType) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Value json.RawMessage `json:"value"`
+		Dir   ChanDir         `json:"dir"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	value, err := UnmarshalType(raw.Value)
+	t.Value, t.Dir = value, raw.Dir
+	return err
+}
+
+func (t *EllipsisType) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	value, err := UnmarshalType(raw.Value)
+	t.Value = value
+	return err
+}
+
+func (t *InstantiationType) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Value json.RawMessage `json:"value"`
+		Args  json.RawMessage `json:"args"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	value, err := UnmarshalType(raw.Value)
+	if err != nil {
+		return err
+	}
+	args, err := unmarshalTypes(raw.Args)
+	t.Value, t.Args = value, args
+	return err
+}
+
+func (t *UnionType) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Terms json.RawMessage `json:"terms"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	terms, err := unmarshalTypes(raw.Terms)
+	t.Terms = terms
+	return err
+}
+
+func (t *ApproximationType) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	value, err := UnmarshalType(raw.Value)
+	t.Value = value
+	return err
+}
+
+func (p *TypeParam) UnmarshalJSON(b []byte) error {
+	type plain TypeParam
+	raw := struct {
+		*plain
+		Constraint json.RawMessage `json:"constraint"`
+	}{plain: (*plain)(p)}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	constraint, err := UnmarshalType(raw.Constraint)
+	p.Constraint = constraint
+	return err
+}
+
+func (p *Param) UnmarshalJSON(b []byte) error {
+	if typ, ok, err := legacyType(b); ok || err != nil {
+		p.Type = typ
+		return err
+	}
+	var raw struct {
+		Name string          `json:"name"`
+		Type json.RawMessage `json:"type"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	typ, err := UnmarshalType(raw.Type)
+	p.Name, p.Type = raw.Name, typ
+	return err
+}
+
+func (d *InterfaceDefinition) UnmarshalJSON(b []byte) error {
+	type plain InterfaceDefinition
+	raw := struct {
+		*plain
+		Embeds  json.RawMessage `json:"embeds"`
+		TypeSet json.RawMessage `json:"typeset"`
+	}{plain: (*plain)(d)}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	embeds, err := unmarshalTypes(raw.Embeds)
+	if err != nil {
+		return err
+	}
+	typeset, err := unmarshalTypes(raw.TypeSet)
+	d.Embeds, d.TypeSet = embeds, typeset
+	return err
+}
+
+func (f *WireField) UnmarshalJSON(b []byte) error {
+	type plain WireField
+	raw := struct {
+		*plain
+		Type json.RawMessage `json:"type"`
+	}{plain: (*plain)(f)}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	typ, err := UnmarshalType(raw.Type)
+	f.Type = typ
+	return err
+}
+
+func (f *PromotedField) UnmarshalJSON(b []byte) error {
+	type plain PromotedField
+	raw := struct {
+		*plain
+		Type json.RawMessage `json:"type"`
+	}{plain: (*plain)(f)}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	typ, err := UnmarshalType(raw.Type)
+	f.Type = typ
+	return err
+}
+
+func (f *Field) UnmarshalJSON(b []byte) error {
+	if typ, ok, err := legacyType(b); ok || err != nil {
+		f.Type = typ
+		return err
+	}
+	type plain Field
+	raw := struct {
+		*plain
+		Type json.RawMessage `json:"type"`
+	}{plain: (*plain)(f)}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	typ, err := UnmarshalType(raw.Type)
+	f.Type = typ
+	return err
+}
+
+func (d *AliasDefinition) UnmarshalJSON(b []byte) error {
+	type plain AliasDefinition
+	raw := struct {
+		*plain
+		Original json.RawMessage `json:"original"`
+	}{plain: (*plain)(d)}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	typ, err := UnmarshalType(raw.Original)
+	d.Original = typ
+	return err
+}
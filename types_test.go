@@ -0,0 +1,85 @@
+package structjson
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestUnmarshalTypeLegacy(t *testing.T) {
+	str := &PrimitiveType{Value: "string"}
+	cases := []struct {
+		msg  string
+		data string
+		want Type
+	}{
+		{msg: "array without len is a slice", data: `{"kind":"array","value":{"kind":"primitive","value":"string"}}`, want: &SliceType{Value: str}},
+		{msg: "array", data: `{"kind":"array","value":{"kind":"primitive","value":"string"},"len":2}`, want: &ArrayType{Value: str, Len: 2}},
+		{
+			msg:  "func with args",
+			data: `{"kind":"func","args":[{"kind":"primitive","value":"string"},{"kind":"ellipsis","value":{"kind":"primitive","value":"string"}}],"results":[{"kind":"primitive","value":"error"}]}`,
+			want: &FuncType{
+				Params:   []*Param{{Type: str}, {Type: &EllipsisType{Value: str}}},
+				Results:  []*Param{{Type: &PrimitiveType{Value: "error"}}},
+				Variadic: true,
+			},
+		},
+		{
+			msg:  "struct with a type list",
+			data: `{"kind":"struct","fields":[{"kind":"primitive","value":"string"},{"kind":"pointer","value":{"kind":"primitive","value":"string"}}]}`,
+			want: &StructType{Fields: []*Field{{Type: str}, {Type: &PointerType{Value: str}}}},
+		},
+		{msg: "chan with integer dir", data: `{"kind":"channel","value":{"kind":"primitive","value":"string"},"dir":1}`, want: &ChanType{Value: str, Dir: ChanSend}},
+	}
+	for _, c := range cases {
+		t.Run(c.msg, func(t *testing.T) {
+			got, err := UnmarshalType([]byte(c.data))
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				gotJSON, _ := json.Marshal(got)
+				wantJSON, _ := json.Marshal(c.want)
+				t.Errorf("want %s\n got %s", wantJSON, gotJSON)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	entries, err := os.ReadDir("examples")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == "output" {
+			continue
+		}
+		t.Run(entry.Name(), func(t *testing.T) {
+			config := NewConfig("./examples/" + entry.Name() + "/")
+			config.Depth = 0
+			w, err := Load(context.Background(), *config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := json.Marshal(w)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var decoded World
+			if err := json.Unmarshal(want, &decoded); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			got, err := json.Marshal(&decoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("the decoded world is different from the original\nwant %s\n got %s", want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package structjson
+
+import (
+	"fmt"
+	"go/token"
+)
+
+// Visibility is the policy of collecting identifiers by their export status.
+type Visibility string
+
+const (
+	VisibilityExported Visibility = "exported" // only exported identifiers
+	VisibilityAll      Visibility = "all"      // exported and unexported identifiers
+)
+
+// ParseVisibility parses s ("exported" or "all").
+func ParseVisibility(s string) (Visibility, error) {
+	switch v := Visibility(s); v {
+	case VisibilityExported, VisibilityAll:
+		return v, nil
+	default:
+		return "", fmt.Errorf("unknown visibility %q (exported, all)", s)
+	}
+}
+
+// includes reports whether the identifier named name is collected.
+func (v Visibility) includes(name string) bool {
+	return v == VisibilityAll || token.IsExported(name)
+}